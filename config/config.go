@@ -2,42 +2,392 @@ package config
 
 import (
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq" 
+	"github.com/XSAM/otelsql"
 	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gopkg.in/yaml.v3"
 )
 
 var Database *sql.DB
 
-func ConnectDB() *sql.DB {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+// Config is the configuration of the API. Load fills it from, in increasing
+// order of precedence, the defaults, an optional YAML file, the environment
+// (including a .env file) and command line flags.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	Currency    CurrencyConfig    `yaml:"currency"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// once the server is asked to stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	MigrateOnStart  bool          `yaml:"migrate_on_start"`
+}
+
+type AuthConfig struct {
+	JWTSecret string        `yaml:"jwt_secret"`
+	TokenTTL  time.Duration `yaml:"token_ttl"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+	AllowMethods []string `yaml:"allow_methods"`
+	AllowHeaders []string `yaml:"allow_headers"`
+}
+
+type AttachmentsConfig struct {
+	Dir string `yaml:"dir"`
+	// SigningKey signs attachment download URLs. It defaults to the JWT secret.
+	SigningKey string `yaml:"signing_key"`
+}
+
+type CurrencyConfig struct {
+	Default           string `yaml:"default"`
+	ExchangeRatesFile string `yaml:"exchange_rates_file"`
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `yaml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the URL of the OTLP/HTTP collector, e.g.
+	// http://localhost:4318. Empty uses the standard OTEL_EXPORTER_OTLP_*
+	// variables or the local default.
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// SlogLevel returns the configured level, info if it is invalid.
+func (c LogConfig) SlogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Default returns the configuration used for everything left unset.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{Port: 8080, ShutdownTimeout: 30 * time.Second},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "require",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			MigrateOnStart:  true,
+		},
+		Auth: AuthConfig{TokenTTL: 24 * time.Hour},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowHeaders: []string{"Content-Type", "Authorization"},
+		},
+		Attachments: AttachmentsConfig{Dir: "attachments"},
+		Currency:    CurrencyConfig{Default: "IDR"},
+		Log:         LogConfig{Level: "info", Format: "json"},
+		Tracing:     TracingConfig{Exporter: "none", ServiceName: "savecash-api", SampleRatio: 1},
+	}
+}
+
+// setting is a configuration value that can be set from the environment and,
+// when flag is not empty, from the command line. Secrets have no flag so they
+// do not show up in process listings.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"PORT", "port", "HTTP port", intSetter(func(c *Config) *int { return &c.Server.Port })},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time allowed for in-flight requests to finish on shutdown, e.g. 30s", durationSetter(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"DB_HOST", "db-host", "database host", stringSetter(func(c *Config) *string { return &c.Database.Host })},
+	{"DB_PORT", "db-port", "database port", intSetter(func(c *Config) *int { return &c.Database.Port })},
+	{"DB_USER", "db-user", "database user", stringSetter(func(c *Config) *string { return &c.Database.User })},
+	{"DB_PASSWORD", "", "", stringSetter(func(c *Config) *string { return &c.Database.Password })},
+	{"DB_NAME", "db-name", "database name", stringSetter(func(c *Config) *string { return &c.Database.Name })},
+	{"DB_SSLMODE", "db-sslmode", "database SSL mode", stringSetter(func(c *Config) *string { return &c.Database.SSLMode })},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections, 0 for no limit", intSetter(func(c *Config) *int { return &c.Database.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", intSetter(func(c *Config) *int { return &c.Database.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum lifetime of a database connection, e.g. 30m", durationSetter(func(c *Config) *time.Duration { return &c.Database.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum idle time of a database connection, e.g. 5m", durationSetter(func(c *Config) *time.Duration { return &c.Database.ConnMaxIdleTime })},
+	{"MIGRATE_ON_START", "migrate-on-start", "apply pending migrations on startup", boolSetter(func(c *Config) *bool { return &c.Database.MigrateOnStart })},
+	{"JWT_SECRET", "", "", stringSetter(func(c *Config) *string { return &c.Auth.JWTSecret })},
+	{"JWT_TTL", "jwt-ttl", "lifetime of issued tokens, e.g. 24h", durationSetter(func(c *Config) *time.Duration { return &c.Auth.TokenTTL })},
+	{"CORS_ALLOW_ORIGINS", "cors-allow-origins", "comma-separated origins allowed by CORS", listSetter(func(c *Config) *[]string { return &c.CORS.AllowOrigins })},
+	{"CORS_ALLOW_METHODS", "cors-allow-methods", "comma-separated methods allowed by CORS", listSetter(func(c *Config) *[]string { return &c.CORS.AllowMethods })},
+	{"CORS_ALLOW_HEADERS", "cors-allow-headers", "comma-separated headers allowed by CORS", listSetter(func(c *Config) *[]string { return &c.CORS.AllowHeaders })},
+	{"ATTACHMENTS_DIR", "attachments-dir", "directory of stored attachments", stringSetter(func(c *Config) *string { return &c.Attachments.Dir })},
+	{"ATTACHMENTS_SIGNING_KEY", "", "", stringSetter(func(c *Config) *string { return &c.Attachments.SigningKey })},
+	{"DEFAULT_CURRENCY", "default-currency", "currency of accounts created without one", stringSetter(func(c *Config) *string { return &c.Currency.Default })},
+	{"LOG_LEVEL", "log-level", "minimum level of logged records: debug, info, warn or error", stringSetter(func(c *Config) *string { return &c.Log.Level })},
+	{"LOG_FORMAT", "log-format", "log format: json or text", stringSetter(func(c *Config) *string { return &c.Log.Format })},
+	{"TRACING_EXPORTER", "tracing-exporter", "trace exporter: none, stdout or otlp", stringSetter(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACING_ENDPOINT", "tracing-endpoint", "URL of the OTLP/HTTP trace collector", stringSetter(func(c *Config) *string { return &c.Tracing.Endpoint })},
+	{"TRACING_SERVICE_NAME", "tracing-service-name", "service name reported in traces", stringSetter(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of new traces recorded, from 0 to 1", floatSetter(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{"EXCHANGE_RATES_FILE", "exchange-rates-file", "CSV file of exchange rates loaded on startup", stringSetter(func(c *Config) *string { return &c.Currency.ExchangeRatesFile })},
+}
+
+func stringSetter(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func intSetter(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func floatSetter(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+func boolSetter(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func durationSetter(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func listSetter(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		var list []string
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+// Load reads the configuration for the command line arguments args, without
+// the program name, and returns it with the arguments left after the flags.
+// The YAML file is named by the -config flag or the CONFIG_FILE variable.
+func Load(args []string) (Config, []string, error) {
+	flags := flag.NewFlagSet("savecash", flag.ContinueOnError)
+	configFile := flags.String("config", "", "YAML configuration file")
+	values := map[string]*string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			values[s.flag] = flags.String(s.flag, "", s.usage)
+		}
 	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	cfg := Default()
+
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return Config{}, nil, fmt.Errorf("failed to read configuration file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, nil, fmt.Errorf("failed to parse configuration file %s: %w", *configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, nil, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(&cfg, *values[s.flag]); err != nil {
+					flagErr = fmt.Errorf("invalid -%s: %w", s.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return Config{}, nil, flagErr
+	}
+
+	if cfg.Attachments.SigningKey == "" {
+		cfg.Attachments.SigningKey = cfg.Auth.JWTSecret
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, flags.Args(), nil
+}
+
+// Validate reports every invalid setting of the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server port %d is out of range", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "shutdown timeout must be positive")
+
+	check(c.Database.Host != "", "database host is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database port %d is out of range", c.Database.Port)
+	check(c.Database.User != "", "database user is required")
+	check(c.Database.Name != "", "database name is required")
+	switch c.Database.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		check(false, "database SSL mode must be disable, require, verify-ca or verify-full")
+	}
+	check(c.Database.MaxOpenConns >= 0, "database max open connections cannot be negative")
+	check(c.Database.MaxIdleConns >= 0, "database max idle connections cannot be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database max idle connections cannot exceed max open connections")
+	check(c.Database.ConnMaxLifetime >= 0, "database connection max lifetime cannot be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database connection max idle time cannot be negative")
 
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	sslmode := os.Getenv("DB_SSLMODE")
+	check(c.Auth.JWTSecret != "", "JWT secret is required")
+	check(c.Auth.TokenTTL > 0, "token TTL must be positive")
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
+	check(len(c.CORS.AllowOrigins) > 0, "at least one CORS origin is required")
+	check(len(c.CORS.AllowMethods) > 0, "at least one CORS method is required")
 
-	db, err := sql.Open("postgres", dsn)
+	check(c.Attachments.Dir != "", "attachments directory is required")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log level must be debug, info, warn or error")
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log format must be json or text")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		check(false, "tracing exporter must be none, stdout or otlp")
+	}
+	check(c.Tracing.ServiceName != "", "tracing service name is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing sample ratio must be between 0 and 1")
+
+	return errors.Join(errs...)
+}
+
+// DSN returns the connection string of the database.
+func (c DatabaseConfig) DSN() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("host='%s' port=%d user='%s' password='%s' dbname='%s' sslmode='%s'",
+		quote.Replace(c.Host), c.Port, quote.Replace(c.User), quote.Replace(c.Password), quote.Replace(c.Name), quote.Replace(c.SSLMode))
+}
+
+// ConnectDB opens the connection pool described by cfg, checks that the
+// database answers and makes the pool available as Database. Every statement
+// run with a context is traced as a child of the context's span.
+func ConnectDB(cfg DatabaseConfig) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DSN(),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	if err := db.Ping(); err != nil {
-		log.Fatalf("Cannot ping database: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("cannot ping database: %w", err)
 	}
 
-	log.Println("Connected to the database successfully!")
-	Database = db 
-	return db
-}
\ No newline at end of file
+	Database = db
+	return db, nil
+}
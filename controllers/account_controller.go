@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create an account
+// @Description This endpoint creates a cash, bank, e-wallet or other account for the authenticated user. Incomes, expenses and transfers are booked against accounts.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param account body models.Account true "Account data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/accounts [post]
+func CreateAccountHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		Currency  string `json:"currency"`
+		IsDefault bool   `json:"is_default"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	account, err := module.CreateAccount(c.UserContext(), intUserID, body.Name, body.Type, body.Currency, body.IsDefault)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"account": account,
+	})
+}
+
+// @Summary Get accounts
+// @Description This endpoint fetches all accounts of the authenticated user with their balances. With a currency, balances are also converted into that reporting currency at the rate of each entry's date.
+// @Tags Accounts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param currency query string false "Reporting currency (ISO 4217)"
+// @Success 200
+// @Failure 500
+// @Router /savecash/accounts [get]
+func GetAccountsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if currency := c.Query("currency"); currency != "" {
+		summary, err := module.GetBalanceSummary(c.UserContext(), intUserID, currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":   "success",
+			"accounts": summary.Accounts,
+			"total":    summary.Total,
+			"currency": summary.Currency,
+		})
+	}
+
+	accounts, err := module.GetAccounts(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching accounts", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch accounts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"accounts": accounts,
+	})
+}
+
+// @Summary Update an account
+// @Description This endpoint renames an account, changes its type or makes it the default account.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Account ID"
+// @Param account body models.Account true "Account data"
+// @Success 200
+// @Failure 400
+// @Router /savecash/accounts/{id} [put]
+func UpdateAccountHandler(c *fiber.Ctx) error {
+	accountID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || accountID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid account ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		IsDefault bool   `json:"is_default"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	account, err := module.UpdateAccount(c.UserContext(), accountID, intUserID, body.Name, body.Type, body.IsDefault)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"account": account,
+	})
+}
+
+// @Summary Transfer between accounts
+// @Description This endpoint moves money from one account of the authenticated user to another. Transfers are neither incomes nor expenses and do not change the total balance.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param transfer body models.Transfer true "Transfer data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/transfers [post]
+func CreateTransferHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		FromAccountID int          `json:"from_account_id"`
+		ToAccountID   int          `json:"to_account_id"`
+		Amount        models.Money `json:"amount"`
+		Note          string       `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	if body.FromAccountID <= 0 || body.ToAccountID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "from_account_id and to_account_id are required",
+		})
+	}
+
+	transfer, err := module.CreateTransfer(c.UserContext(), intUserID, body.FromAccountID, body.ToAccountID, body.Amount, body.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":   "success",
+		"transfer": transfer,
+	})
+}
+
+// @Summary Get transfers
+// @Description This endpoint fetches all transfers between accounts of the authenticated user, most recent first.
+// @Tags Accounts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/transfers [get]
+func GetTransfersHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	transfers, err := module.GetTransfers(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching transfers", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch transfers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":    "success",
+		"transfers": transfers,
+	})
+}
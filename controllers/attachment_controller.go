@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Attach a file to an expense
+// @Description This endpoint attaches a receipt photo or PDF to one of the user's expenses. JPEG, PNG, GIF, WebP and PDF files up to 10 MB are accepted; the type is detected from the content. Uploading the same file twice returns the existing attachment.
+// @Tags Attachments
+// @Accept mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transaction ID"
+// @Param file formData file true "File to attach"
+// @Success 201
+// @Failure 400
+// @Failure 404
+// @Router /savecash/transactions/{id}/attachments [post]
+func UploadTransactionAttachmentHandler(c *fiber.Ctx) error {
+	return uploadAttachment(c, module.AttachmentOwnerTransaction, "transaction")
+}
+
+// @Summary List the attachments of an expense
+// @Description This endpoint lists the files attached to one of the user's expenses, each with a download URL that is valid for 15 minutes.
+// @Tags Attachments
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transaction ID"
+// @Success 200
+// @Router /savecash/transactions/{id}/attachments [get]
+func GetTransactionAttachmentsHandler(c *fiber.Ctx) error {
+	return listAttachments(c, module.AttachmentOwnerTransaction, "transaction")
+}
+
+// @Summary Attach a file to an item
+// @Description This endpoint attaches a product image or PDF to one of the user's items. JPEG, PNG, GIF, WebP and PDF files up to 10 MB are accepted; the type is detected from the content. Uploading the same file twice returns the existing attachment.
+// @Tags Attachments
+// @Accept mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Item ID"
+// @Param file formData file true "File to attach"
+// @Success 201
+// @Failure 400
+// @Failure 404
+// @Router /savecash/items/{id}/attachments [post]
+func UploadItemAttachmentHandler(c *fiber.Ctx) error {
+	return uploadAttachment(c, module.AttachmentOwnerItem, "item")
+}
+
+// @Summary List the attachments of an item
+// @Description This endpoint lists the files attached to one of the user's items, each with a download URL that is valid for 15 minutes.
+// @Tags Attachments
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Item ID"
+// @Success 200
+// @Router /savecash/items/{id}/attachments [get]
+func GetItemAttachmentsHandler(c *fiber.Ctx) error {
+	return listAttachments(c, module.AttachmentOwnerItem, "item")
+}
+
+func uploadAttachment(c *fiber.Ctx, ownerType, ownerName string) error {
+	ownerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ownerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("Invalid %s ID", ownerName),
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A file is required",
+		})
+	}
+	if fileHeader.Size > module.AttachmentMaxSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("File is larger than %d MB", module.AttachmentMaxSize>>20),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	attachment, err := module.CreateAttachment(intUserID, ownerType, ownerID, fileHeader.Filename, file)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"attachment": attachment,
+	})
+}
+
+func listAttachments(c *fiber.Ctx, ownerType, ownerName string) error {
+	ownerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ownerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("Invalid %s ID", ownerName),
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	attachments, err := module.GetAttachments(intUserID, ownerType, ownerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":      "success",
+		"attachments": attachments,
+	})
+}
+
+// @Summary Delete an attachment
+// @Description This endpoint deletes one of the user's attachments. The stored file is removed once no other attachment of the user shares it.
+// @Tags Attachments
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Attachment ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/attachments/{id} [delete]
+func DeleteAttachmentHandler(c *fiber.Ctx) error {
+	attachmentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || attachmentID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid attachment ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteAttachment(attachmentID, intUserID); err != nil {
+		status := fiber.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// @Summary Download an attachment
+// @Description This endpoint serves the content of an attachment. It takes no bearer token; instead the URL must carry the expiry and signature from the attachment's download_url.
+// @Tags Attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200
+// @Failure 403
+// @Failure 404
+// @Router /savecash/attachments/{id}/download [get]
+func DownloadAttachmentHandler(c *fiber.Ctx) error {
+	attachmentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || attachmentID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid attachment ID",
+		})
+	}
+
+	attachment, content, err := module.OpenAttachment(attachmentID, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		status := fiber.StatusForbidden
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	c.Set(fiber.HeaderCacheControl, "private, max-age=900")
+	return c.SendStream(content, int(attachment.Size))
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a budget
+// @Description This endpoint creates a weekly or monthly spending budget for an expense category, in the given currency or that of the default account. Expenses in other currencies count towards it converted at the rate of the period. When enforce is true, expenses that would exceed the budget are rejected; otherwise they are accepted with a warning.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param budget body models.Budget true "Budget data"
+// @Success 201
+// @Failure 400
+// @Failure 500
+// @Router /savecash/budgets [post]
+func CreateBudgetHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Category string       `json:"category"`
+		Amount   models.Money `json:"amount"`
+		Currency string       `json:"currency"`
+		Period   string       `json:"period"`
+		Enforce  bool         `json:"enforce"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	if body.Period == "" {
+		body.Period = module.BudgetPeriodMonthly
+	}
+
+	budget, err := module.CreateBudget(c.UserContext(), intUserID, body.Category, body.Amount, body.Currency, body.Period, body.Enforce)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"budget": budget,
+	})
+}
+
+// @Summary Get budgets
+// @Description This endpoint fetches all budgets of the authenticated user.
+// @Tags Budgets
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/budgets [get]
+func GetBudgetsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	budgets, err := module.GetBudgets(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching budgets", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch budgets",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"budgets": budgets,
+	})
+}
+
+// @Summary Get budget status
+// @Description This endpoint shows, for every budget, the amount spent in the current week or month compared to the budget.
+// @Tags Budgets
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/budgets/status [get]
+func GetBudgetStatusHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	statuses, err := module.GetBudgetStatus(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error computing budget status", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to compute budget status",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"budgets": statuses,
+	})
+}
+
+// @Summary Update a budget
+// @Description This endpoint updates the amount, period and enforcement of a budget owned by the authenticated user.
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Budget ID"
+// @Param budget body models.Budget true "Budget data"
+// @Success 200
+// @Failure 400
+// @Router /savecash/budgets/{id} [put]
+func UpdateBudgetHandler(c *fiber.Ctx) error {
+	budgetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || budgetID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid budget ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Amount  models.Money `json:"amount"`
+		Period  string       `json:"period"`
+		Enforce bool         `json:"enforce"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	if body.Period == "" {
+		body.Period = module.BudgetPeriodMonthly
+	}
+
+	budget, err := module.UpdateBudget(c.UserContext(), budgetID, intUserID, body.Amount, body.Period, body.Enforce)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"budget": budget,
+	})
+}
+
+// @Summary Delete a budget
+// @Description This endpoint deletes a budget owned by the authenticated user.
+// @Tags Budgets
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Budget ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/budgets/{id} [delete]
+func DeleteBudgetHandler(c *fiber.Ctx) error {
+	budgetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || budgetID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid budget ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteBudget(c.UserContext(), budgetID, intUserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Budget deleted successfully",
+	})
+}
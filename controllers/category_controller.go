@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a category
+// @Description This endpoint creates an expense or income category. A category may be nested under a parent category of the same kind.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param category body models.Category true "Category data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/categories [post]
+func CreateCategoryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name     string `json:"name"`
+		Kind     string `json:"kind"`
+		ParentID *int   `json:"parent_id"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	category, err := module.CreateCategory(c.UserContext(), intUserID, body.Name, body.Kind, body.ParentID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":   "success",
+		"category": category,
+	})
+}
+
+// @Summary Get categories
+// @Description This endpoint fetches the category tree of the authenticated user, optionally limited to one kind (expense or income).
+// @Tags Categories
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param kind query string false "expense or income"
+// @Success 200
+// @Failure 500
+// @Router /savecash/categories [get]
+func GetCategoriesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	kind := c.Query("kind")
+	if kind != "" && kind != module.CategoryKindExpense && kind != module.CategoryKindIncome {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "kind must be either expense or income",
+		})
+	}
+
+	categories, err := module.GetCategories(c.UserContext(), intUserID, kind)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching categories", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch categories",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"categories": categories,
+	})
+}
+
+// @Summary Rename a category
+// @Description This endpoint renames a category and rewrites every transaction, income and budget that used the old name.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Category ID"
+// @Success 200
+// @Failure 400
+// @Router /savecash/categories/{id} [put]
+func RenameCategoryHandler(c *fiber.Ctx) error {
+	categoryID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || categoryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid category ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	category, err := module.RenameCategory(c.UserContext(), categoryID, intUserID, body.Name)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"category": category,
+	})
+}
+
+// @Summary Merge a category into another
+// @Description This endpoint merges the category into the target category. Transactions, incomes and budgets are rewritten to the target, child categories are moved under it and the merged category is deleted.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Category ID"
+// @Success 200
+// @Failure 400
+// @Router /savecash/categories/{id}/merge [post]
+func MergeCategoryHandler(c *fiber.Ctx) error {
+	categoryID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || categoryID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid category ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		TargetID int `json:"target_id"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.TargetID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body or target_id",
+		})
+	}
+
+	category, err := module.MergeCategory(c.UserContext(), categoryID, body.TargetID, intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"category": category,
+	})
+}
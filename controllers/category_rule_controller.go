@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a categorization rule
+// @Description This endpoint creates a rule that assigns a category to new and imported expenses without one. A rule matches when all of its conditions hold: the description contains a text, matches a regular expression (case-insensitive), the amount lies within a range, or the expense is booked on a given account. Rules are evaluated by ascending priority and the first match wins.
+// @Tags CategoryRules
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param rule body models.CategoryRule true "Rule data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/category-rules [post]
+func CreateCategoryRuleHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body models.CategoryRule
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := module.CreateCategoryRule(intUserID, body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// @Summary Get categorization rules
+// @Description This endpoint lists the categorization rules of the authenticated user in evaluation order.
+// @Tags CategoryRules
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/category-rules [get]
+func GetCategoryRulesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	rules, err := module.GetCategoryRules(intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching category rules", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch category rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"rules":  rules,
+	})
+}
+
+// @Summary Update a categorization rule
+// @Description This endpoint replaces the conditions, priority and category of a rule owned by the authenticated user.
+// @Tags CategoryRules
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Param rule body models.CategoryRule true "Rule data"
+// @Success 200
+// @Failure 400
+// @Router /savecash/category-rules/{id} [put]
+func UpdateCategoryRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body models.CategoryRule
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := module.UpdateCategoryRule(ruleID, intUserID, body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// @Summary Delete a categorization rule
+// @Description This endpoint deletes a rule owned by the authenticated user. Expenses it already categorized keep their category.
+// @Tags CategoryRules
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/category-rules/{id} [delete]
+func DeleteCategoryRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteCategoryRule(ruleID, intUserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Category rule deleted successfully",
+	})
+}
+
+// @Summary Test a categorization rule
+// @Description This endpoint evaluates an unsaved rule against the existing expenses of the authenticated user and returns the matches (up to 100) with the number that would change category.
+// @Tags CategoryRules
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param rule body models.CategoryRule true "Rule data"
+// @Success 200
+// @Failure 400
+// @Router /savecash/category-rules/test [post]
+func TestCategoryRuleHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body models.CategoryRule
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	result, err := module.TestCategoryRule(intUserID, body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"test":   result,
+	})
+}
+
+// @Summary Re-apply categorization rules
+// @Description This endpoint runs the rules of the authenticated user over all existing expenses and moves every matching expense to the category of its first matching rule. Expenses no rule matches are left alone. With dry_run the changes are listed without being saved.
+// @Tags CategoryRules
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param dry_run query bool false "List the changes without saving them"
+// @Success 200
+// @Failure 500
+// @Router /savecash/category-rules/apply [post]
+func ApplyCategoryRulesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	result, err := module.ApplyCategoryRules(intUserID, c.QueryBool("dry_run"))
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error applying category rules", "user_id", intUserID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to apply category rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"result": result,
+	})
+}
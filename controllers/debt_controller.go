@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a counterparty
+// @Description This endpoint creates a person or organisation the authenticated user lends money to or borrows money from.
+// @Tags Debts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param counterparty body models.Counterparty true "Counterparty data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/counterparties [post]
+func CreateCounterpartyHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name string `json:"name"`
+		Note string `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	counterparty, err := module.CreateCounterparty(intUserID, body.Name, body.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":       "success",
+		"counterparty": counterparty,
+	})
+}
+
+// @Summary Get counterparties
+// @Description This endpoint lists the counterparties of the authenticated user.
+// @Tags Debts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/counterparties [get]
+func GetCounterpartiesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	counterparties, err := module.GetCounterparties(intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching counterparties", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch counterparties",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":         "success",
+		"counterparties": counterparties,
+	})
+}
+
+// @Summary Create a debt
+// @Description This endpoint records money lent to (direction "lent") or borrowed from (direction "borrowed") a counterparty, given by counterparty_id or by name. The debt takes the currency of its account. With term_months a monthly amortization schedule is generated from annual_rate (percent). With record_disbursement the money changing hands is booked as an expense or income as well.
+// @Tags Debts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 201
+// @Failure 400
+// @Router /savecash/debts [post]
+func CreateDebtHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		CounterpartyID     int          `json:"counterparty_id"`
+		Counterparty       string       `json:"counterparty"`
+		Direction          string       `json:"direction"`
+		Principal          models.Money `json:"principal"`
+		AnnualRate         float64      `json:"annual_rate"`
+		TermMonths         *int         `json:"term_months"`
+		StartDate          string       `json:"start_date"`
+		AccountID          int          `json:"account_id"`
+		Note               string       `json:"note"`
+		RecordDisbursement bool         `json:"record_disbursement"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	input := models.Debt{
+		CounterpartyID: body.CounterpartyID,
+		Counterparty:   body.Counterparty,
+		Direction:      body.Direction,
+		Principal:      body.Principal,
+		AnnualRate:     body.AnnualRate,
+		TermMonths:     body.TermMonths,
+		AccountID:      body.AccountID,
+		Note:           body.Note,
+	}
+	if body.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02", body.StartDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "start_date must be a date in YYYY-MM-DD format",
+			})
+		}
+		input.StartDate = startDate
+	}
+
+	debt, err := module.CreateDebt(c.UserContext(), intUserID, input, body.RecordDisbursement)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"debt":   debt,
+	})
+}
+
+// @Summary Get debts
+// @Description This endpoint lists the debts of the authenticated user, open ones first, with the repaid and outstanding amounts and the next scheduled instalment.
+// @Tags Debts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/debts [get]
+func GetDebtsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	debts, err := module.GetDebts(intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching debts", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch debts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"debts":  debts,
+	})
+}
+
+// @Summary Get the outstanding debt summary
+// @Description This endpoint totals what others owe the authenticated user and what the user owes across all open debts, converted into the reporting currency.
+// @Tags Debts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param currency query string false "Reporting currency, defaults to the default account currency"
+// @Success 200
+// @Failure 400
+// @Router /savecash/debts/summary [get]
+func GetDebtSummaryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	summary, err := module.GetDebtSummary(intUserID, c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"summary": summary,
+	})
+}
+
+// @Summary Get a debt
+// @Description This endpoint fetches a debt of the authenticated user with its repayments and amortization schedule.
+// @Tags Debts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Debt ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/debts/{id} [get]
+func GetDebtHandler(c *fiber.Ctx) error {
+	debtID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || debtID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid debt ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	debt, err := module.GetDebt(debtID, intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"debt":   debt,
+	})
+}
+
+// @Summary Get the repayment schedule of a debt
+// @Description This endpoint returns the monthly amortization schedule of a debt with a term: the due date, payment, principal and interest of every instalment and the balance left after it.
+// @Tags Debts
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Debt ID"
+// @Success 200
+// @Failure 400
+// @Router /savecash/debts/{id}/schedule [get]
+func GetDebtScheduleHandler(c *fiber.Ctx) error {
+	debtID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || debtID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid debt ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	schedule, err := module.GetDebtSchedule(debtID, intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"schedule": schedule,
+	})
+}
+
+// @Summary Record a debt repayment
+// @Description This endpoint records a repayment of a debt. Money lent that comes back is booked as an income and a repayment of money borrowed as an expense, under the given category (default "Debt repayment"), on account_id or the debt's account. The amount covers accrued interest first and then reduces the principal.
+// @Tags Debts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Debt ID"
+// @Success 201
+// @Failure 400
+// @Router /savecash/debts/{id}/repayments [post]
+func RecordDebtRepaymentHandler(c *fiber.Ctx) error {
+	debtID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || debtID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid debt ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Amount    models.Money `json:"amount"`
+		AccountID int          `json:"account_id"`
+		Category  string       `json:"category"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	payment, err := module.RecordDebtRepayment(c.UserContext(), debtID, intUserID, body.Amount, body.AccountID, body.Category)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"payment": payment,
+	})
+}
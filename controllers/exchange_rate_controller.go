@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Import exchange rates
+// @Description This endpoint loads daily exchange rates from a CSV file with the header "date,base,quote,rate". The file can be sent as the multipart field "file" or as the raw request body. Existing rates for the same pair and date are replaced.
+// @Tags ExchangeRates
+// @Accept mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param file formData file false "CSV file"
+// @Success 200
+// @Failure 400
+// @Router /savecash/admin/exchange-rates [post]
+func ImportExchangeRatesHandler(c *fiber.Ctx) error {
+	var reader io.Reader = bytes.NewReader(c.Body())
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	count, err := module.LoadExchangeRates(c.UserContext(), reader)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"imported": count,
+	})
+}
+
+// @Summary Get exchange rates
+// @Description This endpoint lists the most recent exchange rates, optionally filtered by base and quote currency.
+// @Tags ExchangeRates
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param base query string false "Base currency"
+// @Param quote query string false "Quote currency"
+// @Success 200
+// @Failure 500
+// @Router /savecash/exchange-rates [get]
+func GetExchangeRatesHandler(c *fiber.Ctx) error {
+	base := strings.ToUpper(strings.TrimSpace(c.Query("base")))
+	quote := strings.ToUpper(strings.TrimSpace(c.Query("quote")))
+
+	rates, err := module.GetExchangeRates(c.UserContext(), base, quote)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching exchange rates", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch exchange rates",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":         "success",
+		"exchange_rates": rates,
+	})
+}
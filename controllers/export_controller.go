@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/Sc01100100/SaveCash-API/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Export a dataset
+// @Description This endpoint streams the transactions, incomes, items or stock transactions of the authenticated user as a CSV or XLSX file.
+// @Tags Export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param Authorization header string true "Bearer token"
+// @Param dataset path string true "transactions, incomes, items or stock-transactions"
+// @Param format query string false "csv (default) or xlsx"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD)"
+// @Param min_amount query string false "Smallest amount"
+// @Param max_amount query string false "Largest amount"
+// @Param category query string false "Expense category"
+// @Param source query string false "Income source"
+// @Param q query string false "Text to search for"
+// @Param tags query string false "Comma-separated tags to filter by"
+// @Param tag_match query string false "any (default) or all"
+// @Param sort query string false "Sort field"
+// @Param order query string false "asc or desc"
+// @Success 200
+// @Failure 400
+// @Router /savecash/export/{dataset} [get]
+func ExportDatasetHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	dataset := c.Params("dataset")
+	if !module.IsExportDataset(dataset) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "dataset must be one of transactions, incomes, items or stock-transactions",
+		})
+	}
+
+	format := c.Query("format", utils.ExportFormatCSV)
+	contentType, ok := utils.ExportContentType(format)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "format must be csv or xlsx",
+		})
+	}
+
+	categoryParam := ""
+	switch dataset {
+	case module.ExportDatasetTransactions:
+		categoryParam = "category"
+	case module.ExportDatasetIncomes:
+		categoryParam = "source"
+	}
+	query, err := parseListQuery(c, categoryParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", dataset, time.Now().Format("20060102"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// The status is already sent once streaming starts, so failures past this
+	// point can only be logged. The stream is written after the handler
+	// returns, when the Fiber context may already serve another request.
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer w.Flush()
+
+		table, err := utils.NewTableWriter(format, w, dataset)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error exporting", "dataset", dataset, "user_id", intUserID, "error", err)
+			return
+		}
+		if err := module.ExportDataset(ctx, intUserID, dataset, query, &flushingTable{TableWriter: table, w: w}); err != nil {
+			slog.ErrorContext(ctx, "Error exporting", "dataset", dataset, "user_id", intUserID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// @Summary Download a monthly statement
+// @Description This endpoint renders the incomes and expenses of the authenticated user for one calendar month as a PDF statement with totals per currency.
+// @Tags Export
+// @Produce application/pdf
+// @Param Authorization header string true "Bearer token"
+// @Param month query string false "Month (YYYY-MM), defaults to the current month"
+// @Param tz query string false "IANA timezone, defaults to UTC"
+// @Success 200
+// @Failure 400
+// @Router /savecash/export/statement [get]
+func ExportStatementHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	from, to, err := module.StatementPeriod(c.Query("month"), c.Query("tz"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="statement-%s.pdf"`, from.Format("2006-01")))
+
+	ctx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer w.Flush()
+
+		if err := module.WriteMonthlyStatement(intUserID, from, to, w); err != nil {
+			slog.ErrorContext(ctx, "Error rendering statement", "user_id", intUserID, "error", err)
+		}
+	})
+
+	return nil
+}
+
+// flushingTable pushes the response buffer to the client whenever the table
+// is flushed.
+type flushingTable struct {
+	utils.TableWriter
+	w *bufio.Writer
+}
+
+func (t *flushingTable) Flush() error {
+	if err := t.TableWriter.Flush(); err != nil {
+		return err
+	}
+	return t.w.Flush()
+}
@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+type goalRequest struct {
+	Name         string       `json:"name"`
+	TargetAmount models.Money `json:"target_amount"`
+	Currency     string       `json:"currency"`
+	Deadline     string       `json:"deadline"`
+	AccountID    *int         `json:"account_id"`
+}
+
+// parseDeadline reads an optional YYYY-MM-DD deadline.
+func parseDeadline(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	deadline, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &deadline, nil
+}
+
+// @Summary Create a savings goal
+// @Description This endpoint creates a savings goal with a target amount and an optional deadline (YYYY-MM-DD). A goal linked to an account measures progress by the account balance; otherwise progress is the money allocated to it through contributions.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param goal body models.Goal true "Goal data"
+// @Success 201
+// @Failure 400
+// @Router /savecash/goals [post]
+func CreateGoalHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body goalRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	deadline, err := parseDeadline(body.Deadline)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "deadline must be a date in YYYY-MM-DD format",
+		})
+	}
+
+	goal, err := module.CreateGoal(c.UserContext(), intUserID, body.Name, body.TargetAmount, body.Currency, deadline, body.AccountID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"goal":   goal,
+	})
+}
+
+// @Summary Get savings goals
+// @Description This endpoint lists the savings goals of the authenticated user with their progress and projected completion date, based on the average net income of the last six months.
+// @Tags Goals
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/goals [get]
+func GetGoalsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	goals, err := module.GetGoals(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching goals", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch goals",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"goals":  goals,
+	})
+}
+
+// @Summary Get a savings goal
+// @Description This endpoint fetches a savings goal of the authenticated user with its progress and contributions.
+// @Tags Goals
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Goal ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/goals/{id} [get]
+func GetGoalHandler(c *fiber.Ctx) error {
+	goalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || goalID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid goal ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	goal, err := module.GetGoal(c.UserContext(), goalID, intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"goal":   goal,
+	})
+}
+
+// @Summary Update a savings goal
+// @Description This endpoint changes the name, target amount and deadline of a savings goal. The linked account and currency cannot be changed.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Goal ID"
+// @Param goal body models.Goal true "Goal data"
+// @Success 200
+// @Failure 400
+// @Router /savecash/goals/{id} [put]
+func UpdateGoalHandler(c *fiber.Ctx) error {
+	goalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || goalID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid goal ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body goalRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	deadline, err := parseDeadline(body.Deadline)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "deadline must be a date in YYYY-MM-DD format",
+		})
+	}
+
+	goal, err := module.UpdateGoal(c.UserContext(), goalID, intUserID, body.Name, body.TargetAmount, deadline)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"goal":   goal,
+	})
+}
+
+// @Summary Delete a savings goal
+// @Description This endpoint deletes a savings goal and its contributions. Money already moved into a linked account stays there.
+// @Tags Goals
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Goal ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/goals/{id} [delete]
+func DeleteGoalHandler(c *fiber.Ctx) error {
+	goalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || goalID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid goal ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteGoal(c.UserContext(), goalID, intUserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Goal deleted successfully",
+	})
+}
+
+// @Summary Contribute to a savings goal
+// @Description This endpoint puts money towards a savings goal. For a goal linked to an account the amount is transferred from from_account_id (the default account when omitted) into the linked account. For other goals it is allocated to the goal; a negative amount releases an allocation.
+// @Tags Goals
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Goal ID"
+// @Success 201
+// @Failure 400
+// @Router /savecash/goals/{id}/contributions [post]
+func AddGoalContributionHandler(c *fiber.Ctx) error {
+	goalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || goalID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid goal ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Amount        models.Money `json:"amount"`
+		FromAccountID int          `json:"from_account_id"`
+		Note          string       `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	contribution, err := module.AddGoalContribution(c.UserContext(), goalID, intUserID, body.Amount, body.FromAccountID, body.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":       "success",
+		"contribution": contribution,
+	})
+}
+
+// @Summary Suggest a monthly contribution
+// @Description This endpoint calculates the monthly contribution needed to reach a savings goal by its deadline and whether the average net income of the last six months covers it.
+// @Tags Goals
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Goal ID"
+// @Success 200
+// @Failure 400
+// @Router /savecash/goals/{id}/suggestion [get]
+func GetGoalSuggestionHandler(c *fiber.Ctx) error {
+	goalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || goalID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid goal ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	suggestion, err := module.SuggestGoalContribution(c.UserContext(), goalID, intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"suggestion": suggestion,
+	})
+}
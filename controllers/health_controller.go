@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/migrations"
+	"github.com/gofiber/fiber/v2"
+)
+
+// readinessTimeout bounds the database checks of the readiness probe.
+const readinessTimeout = 2 * time.Second
+
+var shuttingDown atomic.Bool
+
+// MarkShuttingDown makes the readiness probe fail so that load balancers stop
+// sending requests while the in-flight ones drain.
+func MarkShuttingDown() {
+	shuttingDown.Store(true)
+}
+
+// @Summary Liveness probe
+// @Description This endpoint tells whether the process is running. It does not check any dependency.
+// @Tags Health
+// @Produce json
+// @Success 200
+// @Router /healthz [get]
+func HealthzHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// @Summary Readiness probe
+// @Description This endpoint tells whether the instance can serve requests: the database answers, every migration is applied and the server is not shutting down.
+// @Tags Health
+// @Produce json
+// @Success 200
+// @Failure 503
+// @Router /readyz [get]
+func ReadyzHandler(c *fiber.Ctx) error {
+	if shuttingDown.Load() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Server is shutting down",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+	if err := config.Database.PingContext(ctx); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Database is unreachable",
+		})
+	}
+
+	pending, err := migrations.Pending(ctx, config.Database)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	if len(pending) > 0 {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":             "error",
+			"message":            "Database migrations are pending",
+			"pending_migrations": len(pending),
+		})
+	}
+
+	stats := config.Database.Stats()
+	return c.JSON(fiber.Map{
+		"status": "ok",
+		"database": fiber.Map{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+			"idle":             stats.Idle,
+		},
+	})
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Import transactions from a file
+// @Description This endpoint imports incomes and expenses from a CSV file (described by a JSON column mapping) or an OFX or QIF bank statement into one account. Every row is validated and checked for duplicates against existing entries; accepted rows are committed in a single database transaction. With dry_run the result is returned without saving anything.
+// @Tags Import
+// @Accept mpfd
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param file formData file true "Statement file"
+// @Param format formData string false "csv, ofx or qif, defaults to the file extension"
+// @Param mapping formData string false "CSV column mapping as JSON, e.g. {\"date\":\"Date\",\"amount\":\"Amount\",\"description\":\"Memo\"}"
+// @Param account_id formData int false "Account to book on, defaults to the default account"
+// @Param default_category formData string false "Category for expenses without one, defaults to Uncategorized"
+// @Param dry_run formData bool false "Validate and preview without saving"
+// @Param allow_duplicates formData bool false "Import rows that look like duplicates"
+// @Success 200
+// @Failure 400
+// @Router /savecash/transactions/import [post]
+func ImportTransactionsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A statement file is required",
+		})
+	}
+
+	format := strings.ToLower(c.FormValue("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	if !module.IsImportFormat(format) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "format must be csv, ofx or qif",
+		})
+	}
+
+	var mapping models.ImportMapping
+	if raw := c.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid mapping",
+			})
+		}
+	}
+
+	accountID := 0
+	if raw := c.FormValue("account_id"); raw != "" {
+		if accountID, err = strconv.Atoi(raw); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid account ID",
+			})
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.FormValue("dry_run"))
+	allowDuplicates, _ := strconv.ParseBool(c.FormValue("allow_duplicates"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to read uploaded file",
+		})
+	}
+	defer file.Close()
+
+	rows, err := module.ParseImportFile(format, file, mapping)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	result, err := module.ImportTransactions(c.UserContext(), intUserID, accountID, format, rows, c.FormValue("default_category"), dryRun, allowDuplicates)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error importing transactions", "user_id", intUserID, "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"import": result,
+	})
+}
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/Sc01100100/SaveCash-API/module"
 	"github.com/Sc01100100/SaveCash-API/models"
@@ -16,6 +16,16 @@ import (
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
+// @Param from query string false "First day created (YYYY-MM-DD)"
+// @Param to query string false "Last day created (YYYY-MM-DD)"
+// @Param q query string false "Text to search for in the name and description"
+// @Param tags query string false "Comma-separated tags to filter by"
+// @Param tag_match query string false "any (default) or all"
+// @Param sort query string false "created_at (default), name or stock"
+// @Param order query string false "asc or desc (default)"
+// @Param limit query int false "Page size, 50 by default and at most 500"
+// @Param offset query int false "Number of items to skip"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200
 // @Failure 400
 // @Failure 404
@@ -24,7 +34,7 @@ import (
 func GetItemsHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	if userID == nil {
-		log.Println("UserID is missing in context")
+		slog.WarnContext(c.UserContext(), "UserID is missing in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "UserID is missing in context",
 		})
@@ -32,39 +42,36 @@ func GetItemsHandler(c *fiber.Ctx) error {
 
 	intUserID, ok := userID.(int)
 	if !ok || intUserID == 0 {
-		log.Printf("Invalid UserID from context: %v\n", userID)
+		slog.ErrorContext(c.UserContext(), "Invalid UserID from context", "user_id", userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Invalid UserID format",
 		})
 	}
 
-	rows, err := config.Database.Query(`
-		SELECT id, user_id, name, description, stock, created_at 
-		FROM items 
-		WHERE user_id = $1
-	`, intUserID)
+	query, err := parseListQuery(c, "")
 	if err != nil {
-		log.Printf("Error fetching items for user %d: %v\n", intUserID, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to fetch items",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
-	defer rows.Close()
-
-	items := []models.Item{}
-	for rows.Next() {
-		var item models.Item
-		if err := rows.Scan(&item.ID, &item.UserID, &item.Name, &item.Description, &item.Stock, &item.CreatedAt); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to parse items",
+
+	items, page, err := module.GetItems(c.UserContext(), intUserID, query)
+	if err != nil {
+		if module.IsListQueryError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
 			})
 		}
-		items = append(items, item)
+		slog.ErrorContext(c.UserContext(), "Error fetching items", "user_id", intUserID, "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch items",
+		})
 	}
 
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"items":  items,
+		"page":   withNextLink(c, page),
 	})
 }
 
@@ -82,7 +89,7 @@ func GetItemsHandler(c *fiber.Ctx) error {
 func GetTransactionItemsHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	if userID == nil {
-		log.Println("UserID is missing in context")
+		slog.WarnContext(c.UserContext(), "UserID is missing in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "UserID is missing in context",
 		})
@@ -90,36 +97,19 @@ func GetTransactionItemsHandler(c *fiber.Ctx) error {
 
 	intUserID, ok := userID.(int)
 	if !ok || intUserID == 0 {
-		log.Printf("Invalid UserID from context: %v\n", userID)
+		slog.ErrorContext(c.UserContext(), "Invalid UserID from context", "user_id", userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Invalid UserID format",
 		})
 	}
 
-	rows, err := config.Database.Query(`
-		SELECT id, item_id, item_name, quantity, type, created_at, user_id 
-		FROM stock_transactions 
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-	`, intUserID)
+	transactions, err := module.GetStockTransactions(c.UserContext(), intUserID)
 	if err != nil {
-		log.Printf("Error fetching transactions for user %d: %v\n", intUserID, err)
+		slog.ErrorContext(c.UserContext(), "Error fetching transactions", "user_id", intUserID, "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch transactions",
 		})
 	}
-	defer rows.Close()
-
-	transactions := []models.StockTransaction{}
-	for rows.Next() {
-		var transaction models.StockTransaction
-		if err := rows.Scan(&transaction.ID, &transaction.ItemID, &transaction.ItemName, &transaction.Quantity, &transaction.Type, &transaction.CreatedAt, &transaction.UserID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to parse transactions",
-			})
-		}
-		transactions = append(transactions, transaction)
-	}
 
 	return c.JSON(fiber.Map{
 		"status":       "success",
@@ -174,7 +164,7 @@ func AddItemHandler(c *fiber.Ctx) error {
 		item.UserID, item.Name, item.Description, item.Stock,
 	)
 	if err != nil {
-		log.Printf("Error inserting item: %v\n", err)
+		slog.ErrorContext(c.UserContext(), "Error inserting item", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to add item",
 		})
@@ -237,7 +227,7 @@ func RestockItemHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := module.RestockItem(intUserID, itemID, body.Quantity); err != nil {
+	if err := module.RestockItem(c.UserContext(), intUserID, itemID, body.Quantity); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -249,7 +239,7 @@ func RestockItemHandler(c *fiber.Ctx) error {
 }
 
 // @Summary Sell an item for the authenticated user
-// @Description This endpoint allows the authenticated user to sell an item they own. The user must provide the item ID and the quantity to sell. The item must belong to the user making the request.
+// @Description This endpoint allows the authenticated user to sell an item they own. The user must provide the item ID and the quantity to sell, and may add the sale amount and the account it was received on to record the proceeds as income. The item must belong to the user making the request.
 // @Tags Items
 // @Accept json
 // @Produce json
@@ -270,7 +260,9 @@ func SellItemHandler(c *fiber.Ctx) error {
 	}
 
 	var body struct {
-		Quantity int `json:"quantity"`
+		Quantity  int          `json:"quantity"`
+		AccountID int          `json:"account_id"`
+		Amount    models.Money `json:"amount"`
 	}
 	if err := c.BodyParser(&body); err != nil || body.Quantity <= 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -300,7 +292,7 @@ func SellItemHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := module.SellItem(intUserID, itemID, body.Quantity); err != nil {
+	if err := module.SellItem(c.UserContext(), intUserID, itemID, body.Quantity, body.AccountID, body.Amount); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
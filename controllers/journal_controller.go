@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"log/slog"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get the journal
+// @Description This endpoint fetches the double-entry journal of the authenticated user, most recent entry first. Every income, expense, stock sale and transfer posts one balanced entry.
+// @Tags Journal
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/journal [get]
+func GetJournalHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	entries, err := module.GetJournalEntries(c.UserContext(), intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching journal", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch journal",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"entries": entries,
+	})
+}
+
+// @Summary Check journal integrity
+// @Description This endpoint checks that every journal entry of the authenticated user balances and that the cached account and total balances match the journal.
+// @Tags Journal
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/journal/integrity [get]
+func GetJournalIntegrityHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	return journalIntegrityResponse(c, intUserID)
+}
+
+// @Summary Check journal integrity for all users
+// @Description This endpoint runs the journal integrity check across every user.
+// @Tags Journal
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/admin/journal/integrity [get]
+func GetAllJournalIntegrityHandler(c *fiber.Ctx) error {
+	return journalIntegrityResponse(c, 0)
+}
+
+func journalIntegrityResponse(c *fiber.Ctx, userID int) error {
+	report, err := module.CheckJournalIntegrity(c.UserContext(), userID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error checking journal integrity", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to check journal integrity",
+		})
+	}
+
+	status := "success"
+	if !report.OK {
+		status = "error"
+	}
+
+	return c.JSON(fiber.Map{
+		"status": status,
+		"report": report,
+	})
+}
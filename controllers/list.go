@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListQuery reads the filter, sort and paging parameters shared by the
+// list endpoints and exports. from and to are inclusive YYYY-MM-DD dates in
+// the tz timezone. categoryParam names the category filter, which is "source"
+// for incomes.
+func parseListQuery(c *fiber.Ctx, categoryParam string) (models.ListQuery, error) {
+	query := models.ListQuery{
+		Search: c.Query("q"),
+		Sort:   c.Query("sort"),
+		Order:  c.Query("order"),
+		Cursor: c.Query("cursor"),
+	}
+	if categoryParam != "" {
+		query.Category = c.Query(categoryParam)
+	}
+
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return models.ListQuery{}, fmt.Errorf("unknown timezone %s", tz)
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return models.ListQuery{}, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+		query.From = &start
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return models.ListQuery{}, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+		end = end.AddDate(0, 0, 1)
+		query.To = &end
+	}
+
+	if raw := c.Query("min_amount"); raw != "" {
+		amount, err := models.ParseMoney(raw)
+		if err != nil {
+			return models.ListQuery{}, fmt.Errorf("invalid min_amount")
+		}
+		query.MinAmount = &amount
+	}
+	if raw := c.Query("max_amount"); raw != "" {
+		amount, err := models.ParseMoney(raw)
+		if err != nil {
+			return models.ListQuery{}, fmt.Errorf("invalid max_amount")
+		}
+		query.MaxAmount = &amount
+	}
+
+	var err error
+	if raw := c.Query("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil || query.Limit <= 0 {
+			return models.ListQuery{}, fmt.Errorf("limit must be a positive number")
+		}
+	}
+	if raw := c.Query("offset"); raw != "" {
+		if query.Offset, err = strconv.Atoi(raw); err != nil || query.Offset < 0 {
+			return models.ListQuery{}, fmt.Errorf("offset must not be negative")
+		}
+	}
+
+	if query.Tags, err = module.ParseTagFilter(c.Query("tags"), c.Query("tag_match")); err != nil {
+		return models.ListQuery{}, err
+	}
+
+	return query, nil
+}
+
+// withNextLink sets the link to the page after this one, which repeats the
+// request with the page's cursor in place of any offset.
+func withNextLink(c *fiber.Ctx, page models.Page) models.Page {
+	if page.NextCursor == "" {
+		return page
+	}
+
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+	c.Request().URI().QueryArgs().CopyTo(args)
+	args.Del("offset")
+	args.Set("cursor", page.NextCursor)
+
+	page.Next = c.BaseURL() + c.Path() + "?" + args.String()
+	return page
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Find balance discrepancies
+// @Description This endpoint recomputes every user's balance as SUM(incomes) - SUM(transactions) and lists the users whose cached balance or journal disagrees.
+// @Tags Reconciliation
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/admin/reconciliation [get]
+func GetBalanceDiscrepanciesHandler(c *fiber.Ctx) error {
+	discrepancies, err := module.FindBalanceDiscrepancies(c.UserContext())
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error reconciling balances", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to reconcile balances",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":        "success",
+		"discrepancies": discrepancies,
+	})
+}
+
+// @Summary Repair balances
+// @Description This endpoint corrects the cached balance of the given users, or of every user with a discrepancy when user_ids is empty, and records an audit entry per fix.
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /savecash/admin/reconciliation/repair [post]
+func RepairBalancesHandler(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("user_id").(int)
+	if !ok || adminID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		UserIDs []int  `json:"user_ids"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	body.Reason = strings.TrimSpace(body.Reason)
+	if body.Reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A reason is required for the audit trail",
+		})
+	}
+
+	reconciliations, err := module.RepairBalances(c.UserContext(), body.UserIDs, adminID, body.Reason)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error repairing balances", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":          "error",
+			"message":         err.Error(),
+			"reconciliations": reconciliations,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":          "success",
+		"reconciliations": reconciliations,
+	})
+}
+
+// @Summary Get the reconciliation audit log
+// @Description This endpoint lists every balance correction made through reconciliation, most recent first.
+// @Tags Reconciliation
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/admin/reconciliation/audit [get]
+func GetBalanceReconciliationsHandler(c *fiber.Ctx) error {
+	reconciliations, err := module.GetBalanceReconciliations(c.UserContext())
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching balance reconciliations", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch balance reconciliations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":          "success",
+		"reconciliations": reconciliations,
+	})
+}
@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a recurring income or expense
+// @Description This endpoint creates a rule that posts an income or expense automatically. Frequency is daily, weekly or monthly (every interval units, anchored on start_at) or cron with a five-field cron_expr. The rule stops after end_at when set.
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param rule body models.RecurringRule true "Recurring rule"
+// @Success 201
+// @Failure 400
+// @Router /savecash/recurring [post]
+func CreateRecurringRuleHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		AccountID   int          `json:"account_id"`
+		Kind        string       `json:"kind"`
+		Amount      models.Money `json:"amount"`
+		Category    string       `json:"category"`
+		Description string       `json:"description"`
+		Frequency   string       `json:"frequency"`
+		Interval    int          `json:"interval"`
+		CronExpr    string       `json:"cron_expr"`
+		StartAt     time.Time    `json:"start_at"`
+		EndAt       *time.Time   `json:"end_at"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	if body.Kind != module.CategoryKindExpense && body.Kind != module.CategoryKindIncome {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "kind must be either expense or income",
+		})
+	}
+
+	category, err := module.ResolveCategory(c.UserContext(), intUserID, body.Kind, body.Category)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	rule, err := module.CreateRecurringRule(models.RecurringRule{
+		UserID:      intUserID,
+		AccountID:   body.AccountID,
+		Kind:        body.Kind,
+		Amount:      body.Amount,
+		Category:    category,
+		Description: body.Description,
+		Frequency:   body.Frequency,
+		Interval:    body.Interval,
+		CronExpr:    body.CronExpr,
+		StartAt:     body.StartAt,
+		EndAt:       body.EndAt,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// @Summary Get recurring rules
+// @Description This endpoint fetches all recurring income and expense rules of the authenticated user.
+// @Tags Recurring
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Failure 500
+// @Router /savecash/recurring [get]
+func GetRecurringRulesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	rules, err := module.GetRecurringRules(intUserID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "Error fetching recurring rules", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch recurring rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"rules":  rules,
+	})
+}
+
+// @Summary Get occurrences of a recurring rule
+// @Description This endpoint lists the processed occurrences of a rule (posted, skipped or failed) and the next upcoming ones.
+// @Tags Recurring
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Param upcoming query int false "Number of upcoming occurrences (default 5)"
+// @Success 200
+// @Failure 404
+// @Router /savecash/recurring/{id}/occurrences [get]
+func GetRecurringOccurrencesHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	upcoming := c.QueryInt("upcoming", 5)
+	if upcoming < 0 || upcoming > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "upcoming must be between 0 and 100",
+		})
+	}
+
+	history, next, err := module.GetRecurringOccurrences(ruleID, intUserID, upcoming)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "success",
+		"history":  history,
+		"upcoming": next,
+	})
+}
+
+func setRecurringRulePaused(c *fiber.Ctx, paused bool) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	rule, err := module.SetRecurringRulePaused(ruleID, intUserID, paused)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"rule":   rule,
+	})
+}
+
+// @Summary Pause a recurring rule
+// @Description This endpoint stops a rule from posting new occurrences until it is resumed.
+// @Tags Recurring
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/recurring/{id}/pause [put]
+func PauseRecurringRuleHandler(c *fiber.Ctx) error {
+	return setRecurringRulePaused(c, true)
+}
+
+// @Summary Resume a recurring rule
+// @Description This endpoint resumes a paused rule. Occurrences that fell due while the rule was paused are not posted.
+// @Tags Recurring
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/recurring/{id}/resume [put]
+func ResumeRecurringRuleHandler(c *fiber.Ctx) error {
+	return setRecurringRulePaused(c, false)
+}
+
+// @Summary Skip an occurrence of a recurring rule
+// @Description This endpoint skips an upcoming occurrence so it is never posted. Without due_at the next pending occurrence is skipped.
+// @Tags Recurring
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Success 200
+// @Failure 400
+// @Router /savecash/recurring/{id}/skip [post]
+func SkipRecurringOccurrenceHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		DueAt time.Time `json:"due_at"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	occurrence, err := module.SkipRecurringOccurrence(ruleID, intUserID, body.DueAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"occurrence": occurrence,
+	})
+}
+
+// @Summary Delete a recurring rule
+// @Description This endpoint deletes a recurring rule. Incomes and expenses it already posted are kept.
+// @Tags Recurring
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Rule ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/recurring/{id} [delete]
+func DeleteRecurringRuleHandler(c *fiber.Ctx) error {
+	ruleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ruleID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid rule ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteRecurringRule(ruleID, intUserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Recurring rule deleted successfully",
+	})
+}
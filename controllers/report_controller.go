@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get the cash flow report
+// @Description This endpoint aggregates the incomes and expenses of the authenticated user per day, week, month or year, with net cash flow, savings rate and the change against the previous period. Periods follow the given timezone and amounts are converted into the reporting currency.
+// @Tags Reports
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param granularity query string false "day, week, month (default) or year"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA timezone, defaults to UTC"
+// @Param currency query string false "Reporting currency, defaults to the default account currency"
+// @Success 200
+// @Failure 400
+// @Router /savecash/reports/cashflow [get]
+func GetCashFlowReportHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	report, err := module.GetCashFlowReport(c.UserContext(), intUserID, c.Query("granularity"), c.Query("from"), c.Query("to"), c.Query("tz"), c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"report": report,
+	})
+}
+
+// @Summary Get the category breakdown report
+// @Description This endpoint totals the expenses of the authenticated user per category and the incomes per source over a date range, with each one's share and the change against the preceding range of the same length.
+// @Tags Reports
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA timezone, defaults to UTC"
+// @Param currency query string false "Reporting currency, defaults to the default account currency"
+// @Success 200
+// @Failure 400
+// @Router /savecash/reports/breakdown [get]
+func GetBreakdownReportHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	report, err := module.GetBreakdownReport(c.UserContext(), intUserID, c.Query("from"), c.Query("to"), c.Query("tz"), c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"report": report,
+	})
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Search expenses, incomes and items
+// @Description This endpoint searches the descriptions and categories of the authenticated user's expenses, the sources of their incomes and the names and descriptions of their items. Every word of q must match, also as the start of a longer word. Results are ranked best first, and each carries the matching text with the matched words wrapped in <mark> tags.
+// @Tags Search
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param q query string true "Words to search for"
+// @Param types query string false "Comma-separated result types: transactions, incomes, items"
+// @Param limit query int false "Number of results, 20 by default and at most 100"
+// @Success 200
+// @Failure 400
+// @Router /savecash/search [get]
+func SearchHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var types []string
+	for _, resultType := range strings.Split(c.Query("types"), ",") {
+		if resultType = strings.TrimSpace(resultType); resultType != "" {
+			types = append(types, resultType)
+		}
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "limit must be a positive number",
+			})
+		}
+	}
+
+	results, err := module.Search(c.UserContext(), intUserID, c.Query("q"), types, limit)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if strings.HasPrefix(err.Error(), "failed to") {
+			status = fiber.StatusInternalServerError
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"results": results,
+	})
+}
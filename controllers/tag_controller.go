@@ -0,0 +1,359 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Create a tag
+// @Description This endpoint creates a tag for the authenticated user. Tags are free-form labels such as "trip-bali" or "tax-deductible" shared by expenses, incomes and items; names are unique regardless of case.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body object true "Tag name, e.g. {\"name\":\"trip-bali\"}"
+// @Success 201
+// @Failure 400
+// @Router /savecash/tags [post]
+func CreateTagHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	tag, err := module.CreateTag(c.UserContext(), intUserID, body.Name)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"tag":    tag,
+	})
+}
+
+// @Summary List tags
+// @Description This endpoint lists the tags of the authenticated user with the number of expenses, incomes and items carrying each.
+// @Tags Tags
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200
+// @Router /savecash/tags [get]
+func GetTagsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	tags, err := module.GetTags(c.UserContext(), intUserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"tags":   tags,
+	})
+}
+
+// @Summary Rename a tag
+// @Description This endpoint renames one of the user's tags. Every entry carrying the tag shows the new name.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Tag ID"
+// @Param body body object true "New name, e.g. {\"name\":\"trip-bali-2024\"}"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /savecash/tags/{id} [put]
+func RenameTagHandler(c *fiber.Ctx) error {
+	tagID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || tagID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid tag ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	tag, err := module.RenameTag(c.UserContext(), tagID, intUserID, body.Name)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"tag":    tag,
+	})
+}
+
+// @Summary Delete a tag
+// @Description This endpoint deletes one of the user's tags and removes it from every entry carrying it.
+// @Tags Tags
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Tag ID"
+// @Success 200
+// @Failure 404
+// @Router /savecash/tags/{id} [delete]
+func DeleteTagHandler(c *fiber.Ctx) error {
+	tagID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || tagID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid tag ID",
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	if err := module.DeleteTag(c.UserContext(), tagID, intUserID); err != nil {
+		status := fiber.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tag deleted successfully",
+	})
+}
+
+// @Summary Set the tags of an expense
+// @Description This endpoint replaces the tags of one of the user's expenses. Tags that do not exist yet are created; an empty list removes all tags.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Transaction ID"
+// @Param body body object true "Tags, e.g. {\"tags\":[\"trip-bali\",\"tax-deductible\"]}"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /savecash/transactions/{id}/tags [put]
+func SetTransactionTagsHandler(c *fiber.Ctx) error {
+	return setTags(c, module.TagOwnerTransaction, "transaction")
+}
+
+// @Summary Set the tags of an income
+// @Description This endpoint replaces the tags of one of the user's incomes. Tags that do not exist yet are created; an empty list removes all tags.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Income ID"
+// @Param body body object true "Tags, e.g. {\"tags\":[\"freelance\"]}"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /savecash/incomes/{id}/tags [put]
+func SetIncomeTagsHandler(c *fiber.Ctx) error {
+	return setTags(c, module.TagOwnerIncome, "income")
+}
+
+// @Summary Set the tags of an item
+// @Description This endpoint replaces the tags of one of the user's items. Tags that do not exist yet are created; an empty list removes all tags.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path int true "Item ID"
+// @Param body body object true "Tags, e.g. {\"tags\":[\"seasonal\"]}"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Router /savecash/items/{id}/tags [put]
+func SetItemTagsHandler(c *fiber.Ctx) error {
+	return setTags(c, module.TagOwnerItem, "item")
+}
+
+func setTags(c *fiber.Ctx, owner, ownerName string) error {
+	ownerID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || ownerID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("Invalid %s ID", ownerName),
+		})
+	}
+
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+		})
+	}
+
+	tags, err := module.SetTags(c.UserContext(), intUserID, owner, ownerID, body.Tags)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if strings.Contains(err.Error(), "not found") {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"tags":   tags,
+	})
+}
+
+// @Summary Get the tag report
+// @Description This endpoint totals the expenses and incomes of the authenticated user carrying each tag over a date range. An entry with several tags counts towards each of them.
+// @Tags Reports
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Param tz query string false "IANA timezone, defaults to UTC"
+// @Param currency query string false "Reporting currency, defaults to the default account currency"
+// @Success 200
+// @Failure 400
+// @Router /savecash/reports/tags [get]
+func GetTagReportHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	if userID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "UserID is missing in context",
+		})
+	}
+
+	intUserID, ok := userID.(int)
+	if !ok || intUserID == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid UserID format",
+		})
+	}
+
+	report, err := module.GetTagReport(c.UserContext(), intUserID, c.Query("from"), c.Query("to"), c.Query("tz"), c.Query("currency"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "success",
+		"report": report,
+	})
+}
@@ -2,8 +2,9 @@ package controllers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
+	"strings"
 
 	"github.com/Sc01100100/SaveCash-API/models"
 	"github.com/Sc01100100/SaveCash-API/module"
@@ -13,9 +14,12 @@ import (
 
 func CreateTransactionHandler(c *fiber.Ctx) error {
 	type RequestBody struct {
-		Amount      float64 `json:"amount"`
-		Category    string  `json:"category"`
-		Description string  `json:"description"`
+		AccountID   int          `json:"account_id"`
+		Amount      models.Money `json:"amount"`
+		Category    string       `json:"category"`
+		Description string       `json:"description"`
+
+		Splits []models.TransactionSplit `json:"splits"`
 	}
 
 	var body RequestBody
@@ -50,9 +54,34 @@ func CreateTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	transaction, err := module.CreateTransaction(intUserID, body.Amount, body.Category, body.Description)
+	// A split expense takes its category from its splits. Otherwise, without a
+	// category, the user's categorization rules pick one.
+	category := body.Category
+	if len(body.Splits) == 0 {
+		if strings.TrimSpace(body.Category) == "" {
+			ruleCategory, err := module.CategorizeTransaction(intUserID, body.AccountID, body.Amount, body.Description)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": err.Error(),
+				})
+			}
+			body.Category = ruleCategory
+		}
+
+		resolved, err := module.ResolveCategory(c.UserContext(), intUserID, module.CategoryKindExpense, body.Category)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+		category = resolved
+	}
+
+	transaction, err := module.CreateSplitTransaction(c.UserContext(), intUserID, body.AccountID, body.Amount, category, body.Description, body.Splits)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("insufficient funds: available %.2f, required %.2f", 0.0, body.Amount) {
+		if err.Error() == fmt.Sprintf("insufficient funds: available %s, required %s", models.Money(0), body.Amount) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"status":  "error",
 				"message": err.Error(),
@@ -64,16 +93,42 @@ func CreateTransactionHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+	response := fiber.Map{
 		"status":      "success",
 		"transaction": transaction,
-	})
+	}
+
+	categories := []string{transaction.Category}
+	for _, split := range transaction.Splits {
+		categories = append(categories, split.Category)
+	}
+
+	var warnings []models.BudgetStatus
+	checked := map[string]bool{}
+	for _, category := range categories {
+		if checked[strings.ToLower(category)] {
+			continue
+		}
+		checked[strings.ToLower(category)] = true
+
+		categoryWarnings, err := module.GetBudgetWarnings(c.UserContext(), intUserID, category)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Error checking budgets", "user_id", intUserID, "error", err)
+			continue
+		}
+		warnings = append(warnings, categoryWarnings...)
+	}
+	if len(warnings) > 0 {
+		response["budget_warnings"] = warnings
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 func CreateIncomeHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	if userID == nil {
-		log.Println("UserID is missing in context")
+		slog.WarnContext(c.UserContext(), "UserID is missing in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  "error",
 			"message": "UserID is missing in context",
@@ -82,14 +137,14 @@ func CreateIncomeHandler(c *fiber.Ctx) error {
 
 	intUserID, ok := userID.(int)
 	if !ok || intUserID == 0 {
-		log.Printf("Invalid UserID from context: %v\n", userID)
+		slog.ErrorContext(c.UserContext(), "Invalid UserID from context", "user_id", userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Invalid UserID format",
 		})
 	}
 
-	log.Printf("Creating income for UserID: %d\n", intUserID)
+	slog.DebugContext(c.UserContext(), "Creating income", "user_id", intUserID)
 
 	var income models.Income
 	if err := c.BodyParser(&income); err != nil {
@@ -112,7 +167,15 @@ func CreateIncomeHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	newIncome, err := module.CreateIncome(intUserID, income.Amount, income.Source)
+	source, err := module.ResolveCategory(c.UserContext(), intUserID, module.CategoryKindIncome, income.Source)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+
+	newIncome, err := module.CreateIncome(c.UserContext(), intUserID, income.AccountID, income.Amount, source)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -129,7 +192,7 @@ func CreateIncomeHandler(c *fiber.Ctx) error {
 func GetTransactionsHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	if userID == nil {
-		log.Println("UserID is missing in context")
+		slog.WarnContext(c.UserContext(), "UserID is missing in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "UserID is missing in context",
 		})
@@ -137,15 +200,27 @@ func GetTransactionsHandler(c *fiber.Ctx) error {
 
 	intUserID, ok := userID.(int)
 	if !ok || intUserID == 0 {
-		log.Printf("Invalid UserID from context: %v\n", userID)
+		slog.ErrorContext(c.UserContext(), "Invalid UserID from context", "user_id", userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Invalid UserID format",
 		})
 	}
 
-	transactions, err := module.GetTransactions(intUserID)
+	query, err := parseListQuery(c, "category")
 	if err != nil {
-		log.Printf("Error fetching transactions: %v\n", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	transactions, page, err := module.GetTransactions(c.UserContext(), intUserID, query)
+	if err != nil {
+		if module.IsListQueryError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		slog.ErrorContext(c.UserContext(), "Error fetching transactions", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch transactions",
 		})
@@ -154,13 +229,14 @@ func GetTransactionsHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status":       "success",
 		"transactions": transactions,
+		"page":         withNextLink(c, page),
 	})
 }
 
 func GetIncomesHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	if userID == nil {
-		log.Println("UserID is missing in context")
+		slog.WarnContext(c.UserContext(), "UserID is missing in context")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "UserID is missing in context",
 		})
@@ -168,15 +244,27 @@ func GetIncomesHandler(c *fiber.Ctx) error {
 
 	intUserID, ok := userID.(int)
 	if !ok || intUserID == 0 {
-		log.Printf("Invalid UserID from context: %v\n", userID)
+		slog.ErrorContext(c.UserContext(), "Invalid UserID from context", "user_id", userID)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Invalid UserID format",
 		})
 	}
 
-	incomes, err := module.GetIncomes(intUserID)
+	query, err := parseListQuery(c, "source")
 	if err != nil {
-		log.Printf("Error fetching incomes: %v\n", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	incomes, page, err := module.GetIncomes(c.UserContext(), intUserID, query)
+	if err != nil {
+		if module.IsListQueryError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		slog.ErrorContext(c.UserContext(), "Error fetching incomes", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch incomes",
 		})
@@ -185,6 +273,7 @@ func GetIncomesHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"incomes": incomes,
+		"page":    withNextLink(c, page),
 	})
 }
 
@@ -229,7 +318,7 @@ func DeleteTransactionHandler(c *fiber.Ctx) error {
         })
     }
 
-    err = module.DeleteTransaction(id)
+    err = module.DeleteTransaction(c.UserContext(), id)
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "status":  "error",
@@ -269,9 +358,12 @@ func UpdateTransactionHandler(c *fiber.Ctx) error {
     }
 
     var body struct {
-        Amount      float64 `json:"amount"`
-        Category    string  `json:"category"`
-        Description string  `json:"description"`
+        Amount      models.Money `json:"amount"`
+        Category    string       `json:"category"`
+        Description string       `json:"description"`
+
+        // Omitted splits are kept; an empty list removes them.
+        Splits *[]models.TransactionSplit `json:"splits"`
     }
     if err := c.BodyParser(&body); err != nil {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -280,7 +372,23 @@ func UpdateTransactionHandler(c *fiber.Ctx) error {
         })
     }
 
-    updatedTransaction, err := module.UpdateTransaction(transactionID, intUserID, body.Amount, body.Category, body.Description)
+    var splits []models.TransactionSplit
+    if body.Splits != nil {
+        splits = append([]models.TransactionSplit{}, *body.Splits...)
+    }
+
+    category := body.Category
+    if len(splits) == 0 {
+        category, err = module.ResolveCategory(c.UserContext(), intUserID, module.CategoryKindExpense, body.Category)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "status":  "error",
+                "message": err.Error(),
+            })
+        }
+    }
+
+    updatedTransaction, err := module.UpdateSplitTransaction(c.UserContext(), transactionID, intUserID, body.Amount, category, body.Description, splits)
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "status":  "error",
@@ -319,7 +427,7 @@ func DeleteIncomeHandler(c *fiber.Ctx) error {
         })
     }
 
-    err = module.DeleteIncome(incomeID, intUserID) 
+    err = module.DeleteIncome(c.UserContext(), incomeID, intUserID) 
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "status":  "error",
@@ -359,8 +467,8 @@ func UpdateIncomeHandler(c *fiber.Ctx) error {
     }
 
     var body struct {
-        Amount float64 `json:"amount"`
-        Source string  `json:"source"`
+        Amount models.Money `json:"amount"`
+        Source string       `json:"source"`
     }
     if err := c.BodyParser(&body); err != nil {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -369,7 +477,15 @@ func UpdateIncomeHandler(c *fiber.Ctx) error {
         })
     }
 
-    updatedIncome, err := module.UpdateIncome(incomeID, intUserID, body.Amount, body.Source)
+    source, err := module.ResolveCategory(c.UserContext(), intUserID, module.CategoryKindIncome, body.Source)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "status":  "error",
+            "message": err.Error(),
+        })
+    }
+
+    updatedIncome, err := module.UpdateIncome(c.UserContext(), incomeID, intUserID, body.Amount, source)
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "status":  "error",
@@ -408,7 +524,7 @@ func GetIncomeByIDHandler(c *fiber.Ctx) error {
         })
     }
 
-    income, err := module.GetIncomeByID(incomeID, intUserID) 
+    income, err := module.GetIncomeByID(c.UserContext(), incomeID, intUserID) 
     if err != nil {
         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
             "status":  "error",
@@ -447,7 +563,7 @@ func GetTransactionByIDHandler(c *fiber.Ctx) error {
         })
     }
 
-    transaction, err := module.GetTransactionByID(transactionID, intUserID)
+    transaction, err := module.GetTransactionByID(c.UserContext(), transactionID, intUserID)
     if err != nil {
         return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
             "status":  "error",
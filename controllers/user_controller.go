@@ -3,7 +3,7 @@ package controllers
 import (
 	"strconv"
 	"strings"
-	"log"
+	"log/slog"
 	"regexp"
 
 	"github.com/Sc01100100/SaveCash-API/module"
@@ -14,9 +14,30 @@ import (
 )
 
 func GetAllUser(c *fiber.Ctx) error {
-	users := module.GetAllUsers()
+	query, err := parseListQuery(c, "")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
 
-	if len(users) == 0 {
+	users, page, err := module.GetAllUsers(c.UserContext(), query)
+	if err != nil {
+		if module.IsListQueryError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": err.Error(),
+			})
+		}
+		slog.ErrorContext(c.UserContext(), "GetAllUsers error", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to fetch users",
+		})
+	}
+
+	if page.Total == 0 {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"status":  "error",
 			"message": "No users found",
@@ -28,6 +49,7 @@ func GetAllUser(c *fiber.Ctx) error {
 		"status":  "success",
 		"message": "Users retrieved successfully",
 		"data":    users,
+		"page":    withNextLink(c, page),
 	})
 }
 
@@ -117,7 +139,7 @@ func InsertUser(c *fiber.Ctx) error {
 		body.Role = "user"
 	}
 
-	insertedID, err := module.InsertUser(body.Name, body.Email, body.Password, body.Role)
+	insertedID, err := module.InsertUser(c.UserContext(), body.Name, body.Email, body.Password, body.Role)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -160,7 +182,7 @@ func LoginUser(c *fiber.Ctx) error {
 		})
 	}
 
-	userID, role, err := module.LoginUser(body.Email, body.Password)
+	userID, role, err := module.LoginUser(c.UserContext(), body.Email, body.Password)
 	if err != nil {
 		status := fiber.StatusUnauthorized
 		if err.Error() == "user not found" {
@@ -218,7 +240,7 @@ func LogoutUser(c *fiber.Ctx) error {
 	query := `INSERT INTO token_blacklist (token) VALUES ($1)`
 	_, err := config.Database.Exec(query, token)
 	if err != nil {
-		log.Printf("Error blacklisting token: %v\n", err)
+		slog.ErrorContext(c.UserContext(), "Error blacklisting token", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Failed to logout",
@@ -249,8 +271,8 @@ func GetUserInfo(c *fiber.Ctx) error {
 	}
 
 	var user models.User
-	query := `SELECT name, balance FROM users WHERE id = $1`
-	err := config.Database.QueryRow(query, intUserID).Scan(&user.Name, &user.Balance)
+	query := `SELECT name FROM users WHERE id = $1`
+	err := config.Database.QueryRow(query, intUserID).Scan(&user.Name)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -258,11 +280,34 @@ func GetUserInfo(c *fiber.Ctx) error {
 		})
 	}
 
+	currency := c.Query("currency")
+	if currency == "" {
+		currency, err = module.GetReportingCurrency(c.UserContext(), intUserID)
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Error fetching reporting currency", "user_id", intUserID, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to retrieve user information",
+			})
+		}
+	}
+
+	summary, err := module.GetBalanceSummary(c.UserContext(), intUserID, currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	}
+	user.Balance = summary.Total
+
 	return c.JSON(fiber.Map{
 		"status": "success",
 		"data": fiber.Map{
-			"name":    user.Name,
-			"balance": user.Balance,
+			"name":     user.Name,
+			"balance":  user.Balance,
+			"currency": summary.Currency,
+			"accounts": summary.Accounts,
 		},
 	})
 }
\ No newline at end of file
@@ -1,12 +1,27 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"github.com/joho/godotenv"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/controllers"
+	"github.com/Sc01100100/SaveCash-API/metrics"
+	"github.com/Sc01100100/SaveCash-API/migrations"
+	"github.com/Sc01100100/SaveCash-API/module"
+	"github.com/Sc01100100/SaveCash-API/middlewares"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/Sc01100100/SaveCash-API/routes"
+	"github.com/Sc01100100/SaveCash-API/tracing"
+	"github.com/Sc01100100/SaveCash-API/utils"
 	_ "github.com/Sc01100100/SaveCash-API/docs"
 )
 
@@ -22,25 +37,124 @@ import (
 // @BasePath /
 // @schemes http
 func main() {
-	err := godotenv.Load()
+	cfg, args, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	config.ConnectDB()
+	slog.SetDefault(utils.NewLogger(os.Stderr, cfg.Log.Format, cfg.Log.SlogLevel()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	if _, err := config.ConnectDB(cfg.Database); err != nil {
+		fatal("Database connection failed", err)
+	}
 	defer config.Database.Close()
 
-	log.Println("Database connection established.")
+	slog.Info("Database connection established")
+
+	if err := metrics.RegisterDB(config.Database); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(config.Database, args[1:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	if cfg.Database.MigrateOnStart {
+		if _, err := migrations.Up(config.Database); err != nil {
+			fatal("Failed to migrate database", err)
+		}
+	}
+
+	utils.ConfigureJWT(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	module.ConfigureAttachments(cfg.Attachments.Dir, cfg.Attachments.SigningKey)
+	if err := module.SetDefaultCurrency(cfg.Currency.Default); err != nil {
+		fatal("Invalid default currency", err)
+	}
+
+	if path := cfg.Currency.ExchangeRatesFile; path != "" {
+		count, err := module.LoadExchangeRatesFile(context.Background(), path)
+		if err != nil {
+			slog.Error("Failed to load exchange rates", "error", err)
+		} else {
+			slog.Info("Loaded exchange rates", "count", count, "file", path)
+		}
+	}
+
+	// The first SIGINT or SIGTERM starts a graceful shutdown; a second one
+	// kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	app := fiber.New()
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		module.StartRecurringScheduler(ctx, time.Minute)
+	}()
+	go func() {
+		defer workers.Done()
+		module.StartBalanceReconciler(ctx, time.Hour)
+	}()
 
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", 
-		AllowMethods: "GET,POST,PUT,DELETE",
-		AllowHeaders: "Content-Type, Authorization",
-	}))
+	// Leave room for attachment uploads plus the multipart overhead.
+	app := fiber.New(fiber.Config{
+		BodyLimit: module.AttachmentMaxSize + 1<<20,
+	})
+
+	app.Use(middlewares.RequestID())
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.RequestLogger())
+	app.Use(middlewares.Metrics())
+	app.Use(cors.New(middlewares.Cors(cfg.CORS)))
 
 	routes.SetupRoutes(app)
 
-	log.Fatal(app.Listen(":8080"))
-}
\ No newline at end of file
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%d", cfg.Server.Port))
+	}()
+
+	failed := false
+	select {
+	case err := <-listenErr:
+		slog.Error("Server failed", "error", err)
+		failed = true
+	case <-ctx.Done():
+		stop()
+		slog.Info("Shutting down, draining in-flight requests")
+		controllers.MarkShuttingDown()
+		if err := app.ShutdownWithTimeout(cfg.Server.ShutdownTimeout); err != nil {
+			slog.Error("Server shutdown", "error", err)
+		}
+	}
+
+	// Background workers finish the run in progress before returning.
+	stop()
+	workers.Wait()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	cancel()
+
+	if failed {
+		config.Database.Close()
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs err and exits. Deferred calls do not run.
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
+}
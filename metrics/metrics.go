@@ -0,0 +1,110 @@
+// Package metrics exposes Prometheus metrics about HTTP requests, the
+// database connection pool and business events. Business counters are
+// incremented once the change they count is committed.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "savecash"
+
+// Sources tell how incomes and expenses were created.
+const (
+	SourceAPI       = "api"
+	SourceImport    = "import"
+	SourceRecurring = "recurring"
+	SourceStockSale = "stock_sale"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	incomesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "incomes_created_total",
+		Help:      "Incomes recorded, by source.",
+	}, []string{"source"})
+
+	expensesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "expenses_created_total",
+		Help:      "Expenses recorded, by source.",
+	}, []string{"source"})
+
+	failedLogins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_logins_total",
+		Help:      "Rejected login attempts, by reason.",
+	}, []string{"reason"})
+
+	insufficientFunds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_funds_total",
+		Help:      "Operations rejected because the account balance was too low, by operation.",
+	}, []string{"operation"})
+
+	itemsSold = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "items_sold_total",
+		Help:      "Units of stock sold.",
+	})
+)
+
+// ObserveRequest records a served HTTP request. route is the route pattern,
+// not the requested path, to keep the number of series bounded.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func IncomesCreated(source string, count int) {
+	incomesCreated.WithLabelValues(source).Add(float64(count))
+}
+
+func ExpensesCreated(source string, count int) {
+	expensesCreated.WithLabelValues(source).Add(float64(count))
+}
+
+func FailedLogin(reason string) {
+	failedLogins.WithLabelValues(reason).Inc()
+}
+
+func InsufficientFunds(operation string) {
+	insufficientFunds.WithLabelValues(operation).Inc()
+}
+
+func ItemsSold(quantity int) {
+	itemsSold.Add(float64(quantity))
+}
+
+// RegisterDB exposes the statistics of the database connection pool.
+func RegisterDB(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -1,20 +1,19 @@
 package middlewares
 
 import (
-    "strings"
+	"strings"
 
-    "github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
-var origins = []string{
-    "https://nekowawolf.xyz",
-    "https://nekowawolf.github.io",
-    "https://sc01100100.github.io",
+// Cors returns the CORS settings for the configured origins, methods and
+// headers.
+func Cors(cfg config.CORSConfig) cors.Config {
+	return cors.Config{
+		AllowOrigins:  strings.Join(cfg.AllowOrigins, ","),
+		AllowMethods:  strings.Join(cfg.AllowMethods, ","),
+		AllowHeaders:  strings.Join(cfg.AllowHeaders, ", "),
+		ExposeHeaders: "Content-Length, " + RequestIDHeader,
+	}
 }
-
-var Cors = cors.Config{
-    AllowOrigins:     strings.Join(origins[:], ","),
-    AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-    ExposeHeaders:    "Content-Length",
-    AllowCredentials: true,
-}
\ No newline at end of file
@@ -1,7 +1,7 @@
 package middlewares
 
 import (
-	"log"
+	"log/slog"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -25,7 +25,7 @@ func AuthMiddleware() fiber.Handler {
 
 		token := c.Get("Authorization")
 		if token == "" {
-			log.Println("Authorization token is missing")
+			slog.DebugContext(c.UserContext(), "Authorization token is missing")
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "Authorization token is missing",
@@ -36,17 +36,15 @@ func AuthMiddleware() fiber.Handler {
 
 		userID, userRole, err := utils.ValidateJWT(token)
 		if err != nil {
-			log.Printf("Token validation error: %v\n", err)
+			slog.WarnContext(c.UserContext(), "Token validation failed", "error", err)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "Invalid token",
 			})
 		}
 
-		log.Printf("Middleware extracted UserID: %d, Role: %s\n", userID, userRole)
-
 		if userID == 0 {
-			log.Println("Extracted UserID is 0, invalid token")
+			slog.WarnContext(c.UserContext(), "Token carries no UserID")
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  "error",
 				"message": "Invalid UserID in token",
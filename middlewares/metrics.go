@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the count and latency of requests per route.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			// Requests matching no route would otherwise create a series
+			// per path.
+			if status == fiber.StatusNotFound {
+				route = "unmatched"
+			}
+		}
+
+		// Fiber reuses the buffer behind the method for later requests on
+		// the connection, while Prometheus keeps label values as they are.
+		metrics.ObserveRequest(strings.Clone(c.Method()), route, status, time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDHeader carries the ID of a request, both ways.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID accepts the IDs clients and proxies commonly send, and
+// nothing that could forge log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._\-]{1,128}$`)
+
+// RequestID gives every request an ID, reusing a well-formed one sent by the
+// client or a proxy. The ID is returned in the response header and carried
+// by the request context into every log line of the request.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				return err
+			}
+			id = hex.EncodeToString(buf)
+		}
+
+		c.Set(RequestIDHeader, id)
+		c.Locals("request_id", id)
+		c.SetUserContext(utils.WithRequestID(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
+
+// RequestLogger logs one line per request with its outcome and duration.
+// The query string is left out since it may carry signed URLs.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		}
+		if userID, ok := c.Locals("user_id").(int); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		slog.Log(c.UserContext(), level, "Request handled", attrs...)
+
+		return err
+	}
+}
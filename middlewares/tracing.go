@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/tracing"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for every request, continuing the trace of the
+// caller when it sends a traceparent header. The span travels in the request
+// context, so the database statements run by the handler are traced under it.
+func Tracing() fiber.Handler {
+	tracer := tracing.Tracer()
+	return func(c *fiber.Ctx) error {
+		header := http.Header{}
+		c.Request().Header.VisitAll(func(key, value []byte) {
+			header.Add(string(key), string(value))
+		})
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), propagation.HeaderCarrier(header))
+
+		// Fiber reuses the buffers behind the method and the path once the
+		// request is served, before the span is exported.
+		method := strings.Clone(c.Method())
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(strings.Clone(c.Path())),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			if status == fiber.StatusNotFound {
+				route = "unmatched"
+			}
+		}
+
+		// The route pattern is only known once the request is routed.
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		// Client errors are not failures of the server.
+		if status >= fiber.StatusInternalServerError {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
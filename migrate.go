@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Sc01100100/SaveCash-API/migrations"
+)
+
+const migrateUsage = `usage: migrate [command]
+
+commands:
+  up               apply every pending migration (default)
+  down [n]         revert the last n applied migrations, 1 by default
+  status           list the migrations and when they were applied
+  force <version>  record the migrations up to version as applied without
+                   running them, for databases created before migrations
+                   were versioned; 0 clears the history`
+
+// runMigrate runs the migrate subcommand with its arguments.
+func runMigrate(db *sql.DB, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+		applied, err := migrations.Up(db)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) == 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps <= 0 {
+				return fmt.Errorf("down takes a positive number of migrations")
+			}
+		} else if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+		reverted, err := migrations.Down(db, steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migration to revert")
+		}
+		return nil
+
+	case "status":
+		if len(args) != 0 {
+			return errors.New(migrateUsage)
+		}
+		statuses, err := migrations.Statuses(db)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-24s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+
+	case "force":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			return fmt.Errorf("force takes a migration version")
+		}
+		if err := migrations.Force(db, version); err != nil {
+			return err
+		}
+		fmt.Printf("Migration history set to version %d\n", version)
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,6 @@
+DROP TABLE stock_transactions;
+DROP TABLE items;
+DROP TABLE transactions;
+DROP TABLE incomes;
+DROP TABLE token_blacklist;
+DROP TABLE users;
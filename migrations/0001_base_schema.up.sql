@@ -0,0 +1,64 @@
+-- Tables that predate versioned migrations, as they were before amounts became
+-- exact decimals. Later migrations evolve them exactly as they evolved the
+-- databases created by hand, so fresh and existing databases end up alike.
+
+CREATE TABLE users (
+    id       SERIAL PRIMARY KEY,
+    name     TEXT NOT NULL,
+    email    TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role     TEXT NOT NULL DEFAULT 'user',
+    balance  DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE TABLE token_blacklist (
+    id         SERIAL PRIMARY KEY,
+    token      TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX token_blacklist_token_idx ON token_blacklist (token);
+
+CREATE TABLE incomes (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    amount     DOUBLE PRECISION NOT NULL,
+    source     TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX incomes_user_created_idx ON incomes (user_id, created_at);
+
+CREATE TABLE transactions (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users (id),
+    amount      DOUBLE PRECISION NOT NULL,
+    category    TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX transactions_user_created_idx ON transactions (user_id, created_at);
+
+CREATE TABLE items (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users (id),
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    stock       INT NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX items_user_idx ON items (user_id);
+
+CREATE TABLE stock_transactions (
+    id         SERIAL PRIMARY KEY,
+    item_id    INT NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    item_name  TEXT NOT NULL,
+    quantity   INT NOT NULL,
+    type       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id    INT NOT NULL REFERENCES users (id)
+);
+
+CREATE INDEX stock_transactions_user_idx ON stock_transactions (user_id, created_at);
@@ -0,0 +1,14 @@
+-- Spending limits per expense category and week or month. Enforced budgets
+-- reject expenses that would exceed them, the others only warn.
+
+CREATE TABLE budgets (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    category   TEXT NOT NULL,
+    amount     DOUBLE PRECISION NOT NULL,
+    period     TEXT NOT NULL,
+    enforce    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX budgets_user_category_period_idx ON budgets (user_id, LOWER(category), period);
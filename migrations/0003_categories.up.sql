@@ -0,0 +1,13 @@
+-- Managed expense and income categories, nested through parent_id. Entries
+-- keep referring to their category by name.
+
+CREATE TABLE categories (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    name       TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    parent_id  INT REFERENCES categories (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX categories_user_kind_name_idx ON categories (user_id, kind, LOWER(name));
@@ -0,0 +1,32 @@
+-- Rules that post incomes and expenses on a schedule, and one occurrence per
+-- due date so that a date is never posted twice.
+
+CREATE TABLE recurring_rules (
+    id          SERIAL PRIMARY KEY,
+    user_id     INT NOT NULL REFERENCES users (id),
+    kind        TEXT NOT NULL,
+    amount      DOUBLE PRECISION NOT NULL,
+    category    TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    frequency   TEXT NOT NULL,
+    interval    INT NOT NULL DEFAULT 1,
+    cron_expr   TEXT NOT NULL DEFAULT '',
+    start_at    TIMESTAMP NOT NULL,
+    end_at      TIMESTAMP,
+    next_run_at TIMESTAMP,
+    paused      BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX recurring_rules_next_run_idx ON recurring_rules (next_run_at) WHERE NOT paused;
+
+CREATE TABLE recurring_occurrences (
+    id         SERIAL PRIMARY KEY,
+    rule_id    INT NOT NULL REFERENCES recurring_rules (id) ON DELETE CASCADE,
+    due_at     TIMESTAMP NOT NULL,
+    status     TEXT NOT NULL,
+    entry_id   INT,
+    error      TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (rule_id, due_at)
+);
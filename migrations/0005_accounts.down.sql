@@ -0,0 +1,6 @@
+ALTER TABLE recurring_rules DROP COLUMN account_id;
+ALTER TABLE transactions DROP COLUMN account_id;
+ALTER TABLE incomes DROP COLUMN account_id;
+
+DROP TABLE transfers;
+DROP TABLE accounts;
@@ -0,0 +1,32 @@
+-- Accounts a user keeps money in, and transfers between them. Existing
+-- entries have no account until the user's default account is created, which
+-- then takes them over together with users.balance.
+
+CREATE TABLE accounts (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    balance    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX accounts_user_name_idx ON accounts (user_id, LOWER(name));
+CREATE UNIQUE INDEX accounts_user_default_idx ON accounts (user_id) WHERE is_default;
+
+CREATE TABLE transfers (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users (id),
+    from_account_id INT NOT NULL REFERENCES accounts (id),
+    to_account_id   INT NOT NULL REFERENCES accounts (id),
+    amount          DOUBLE PRECISION NOT NULL,
+    note            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX transfers_user_idx ON transfers (user_id);
+
+ALTER TABLE incomes ADD COLUMN account_id INT REFERENCES accounts (id);
+ALTER TABLE transactions ADD COLUMN account_id INT REFERENCES accounts (id);
+ALTER TABLE recurring_rules ADD COLUMN account_id INT REFERENCES accounts (id);
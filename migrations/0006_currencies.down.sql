@@ -0,0 +1,9 @@
+ALTER TABLE transfers
+    DROP COLUMN exchange_rate,
+    DROP COLUMN to_amount;
+
+ALTER TABLE transactions DROP COLUMN currency;
+ALTER TABLE incomes DROP COLUMN currency;
+ALTER TABLE accounts DROP COLUMN currency;
+
+DROP TABLE exchange_rates;
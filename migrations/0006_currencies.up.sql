@@ -0,0 +1,24 @@
+-- Accounts and entries carry an ISO 4217 currency, and transfers between
+-- accounts in different currencies record the converted amount and the rate.
+-- Accounts opened before currencies existed were kept in IDR, the default
+-- currency. Entries without a currency take that of their account.
+
+CREATE TABLE exchange_rates (
+    base      TEXT NOT NULL,
+    quote     TEXT NOT NULL,
+    rate_date DATE NOT NULL,
+    rate      DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (base, quote, rate_date)
+);
+
+ALTER TABLE accounts ADD COLUMN currency TEXT NOT NULL DEFAULT 'IDR';
+ALTER TABLE accounts ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE incomes ADD COLUMN currency TEXT;
+ALTER TABLE transactions ADD COLUMN currency TEXT;
+
+ALTER TABLE transfers
+    ADD COLUMN to_amount DOUBLE PRECISION,
+    ADD COLUMN exchange_rate DOUBLE PRECISION NOT NULL DEFAULT 1;
+UPDATE transfers SET to_amount = amount;
+ALTER TABLE transfers ALTER COLUMN to_amount SET NOT NULL;
@@ -0,0 +1,14 @@
+-- Amounts go back to floating point. Values keep their four fractional
+-- digits but lose exactness again.
+
+ALTER TABLE users ALTER COLUMN balance TYPE DOUBLE PRECISION;
+ALTER TABLE incomes ALTER COLUMN amount TYPE DOUBLE PRECISION;
+ALTER TABLE transactions ALTER COLUMN amount TYPE DOUBLE PRECISION;
+ALTER TABLE accounts ALTER COLUMN balance TYPE DOUBLE PRECISION;
+
+ALTER TABLE transfers
+    ALTER COLUMN amount TYPE DOUBLE PRECISION,
+    ALTER COLUMN to_amount TYPE DOUBLE PRECISION;
+
+ALTER TABLE budgets ALTER COLUMN amount TYPE DOUBLE PRECISION;
+ALTER TABLE recurring_rules ALTER COLUMN amount TYPE DOUBLE PRECISION;
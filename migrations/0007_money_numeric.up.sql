@@ -0,0 +1,39 @@
+-- Store every monetary amount as an exact decimal with four fractional digits
+-- (see models.Money). Existing floating point values are rounded half away
+-- from zero, which is how ROUND behaves on NUMERIC.
+
+ALTER TABLE users
+    ALTER COLUMN balance TYPE NUMERIC(20, 4) USING ROUND(balance::NUMERIC, 4);
+
+ALTER TABLE incomes
+    ALTER COLUMN amount TYPE NUMERIC(20, 4) USING ROUND(amount::NUMERIC, 4);
+
+ALTER TABLE transactions
+    ALTER COLUMN amount TYPE NUMERIC(20, 4) USING ROUND(amount::NUMERIC, 4);
+
+ALTER TABLE accounts
+    ALTER COLUMN balance TYPE NUMERIC(20, 4) USING ROUND(balance::NUMERIC, 4);
+
+ALTER TABLE transfers
+    ALTER COLUMN amount TYPE NUMERIC(20, 4) USING ROUND(amount::NUMERIC, 4),
+    ALTER COLUMN to_amount TYPE NUMERIC(20, 4) USING ROUND(to_amount::NUMERIC, 4);
+
+ALTER TABLE budgets
+    ALTER COLUMN amount TYPE NUMERIC(20, 4) USING ROUND(amount::NUMERIC, 4);
+
+ALTER TABLE recurring_rules
+    ALTER COLUMN amount TYPE NUMERIC(20, 4) USING ROUND(amount::NUMERIC, 4);
+
+-- Balances were maintained by repeated float additions; recompute the cached
+-- account balances from the rounded entries so that no drift survives.
+UPDATE accounts a SET balance = ROUND(a.balance - drift.amount, 4)
+FROM (
+    SELECT a.id,
+           a.balance
+           - COALESCE((SELECT SUM(amount) FROM incomes WHERE account_id = a.id), 0)
+           + COALESCE((SELECT SUM(amount) FROM transactions WHERE account_id = a.id), 0)
+           + COALESCE((SELECT SUM(amount) FROM transfers WHERE from_account_id = a.id), 0)
+           - COALESCE((SELECT SUM(to_amount) FROM transfers WHERE to_account_id = a.id), 0) AS amount
+    FROM accounts a
+) drift
+WHERE drift.id = a.id AND ABS(drift.amount) < 0.01;
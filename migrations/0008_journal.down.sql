@@ -0,0 +1,4 @@
+-- Balances stay as they are; only the journal behind them is dropped.
+
+DROP TABLE journal_lines;
+DROP TABLE journal_entries;
@@ -0,0 +1,105 @@
+-- Double-entry journal behind every balance change. Each entry balances per
+-- currency; asset lines point at an account, income and expense lines are
+-- named after the source or category.
+
+CREATE TABLE journal_entries (
+    id             SERIAL PRIMARY KEY,
+    user_id        INT NOT NULL REFERENCES users (id),
+    kind           TEXT NOT NULL,
+    reference_type TEXT,
+    reference_id   INT,
+    description    TEXT NOT NULL DEFAULT '',
+    posted_at      TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX journal_entries_user_posted_idx ON journal_entries (user_id, posted_at);
+CREATE INDEX journal_entries_reference_idx ON journal_entries (reference_type, reference_id);
+
+CREATE TABLE journal_lines (
+    id         SERIAL PRIMARY KEY,
+    entry_id   INT NOT NULL REFERENCES journal_entries (id),
+    ledger     TEXT NOT NULL CHECK (ledger IN ('asset', 'income', 'expense', 'equity', 'exchange')),
+    account_id INT REFERENCES accounts (id),
+    name       TEXT NOT NULL DEFAULT '',
+    currency   TEXT NOT NULL,
+    debit      NUMERIC(20, 4) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit     NUMERIC(20, 4) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    CHECK (debit = 0 OR credit = 0),
+    CHECK (ledger <> 'asset' OR account_id IS NOT NULL)
+);
+
+CREATE INDEX journal_lines_entry_idx ON journal_lines (entry_id);
+CREATE INDEX journal_lines_account_idx ON journal_lines (account_id);
+
+-- Replay the history recorded since accounts were introduced. Entries of users
+-- who never used accounts are posted by the opening balance of their default
+-- account once it is created.
+
+INSERT INTO journal_entries (user_id, kind, reference_type, reference_id, description, posted_at)
+SELECT user_id, 'income', 'incomes', id, source, created_at FROM incomes WHERE account_id IS NOT NULL;
+
+INSERT INTO journal_lines (entry_id, ledger, account_id, name, currency, debit, credit)
+SELECT e.id, 'asset', i.account_id, '', i.currency, i.amount, 0
+FROM journal_entries e JOIN incomes i ON e.reference_type = 'incomes' AND e.reference_id = i.id
+UNION ALL
+SELECT e.id, 'income', NULL, i.source, i.currency, 0, i.amount
+FROM journal_entries e JOIN incomes i ON e.reference_type = 'incomes' AND e.reference_id = i.id;
+
+INSERT INTO journal_entries (user_id, kind, reference_type, reference_id, description, posted_at)
+SELECT user_id, 'expense', 'transactions', id, description, created_at FROM transactions WHERE account_id IS NOT NULL;
+
+INSERT INTO journal_lines (entry_id, ledger, account_id, name, currency, debit, credit)
+SELECT e.id, 'expense', NULL, t.category, t.currency, t.amount, 0
+FROM journal_entries e JOIN transactions t ON e.reference_type = 'transactions' AND e.reference_id = t.id
+UNION ALL
+SELECT e.id, 'asset', t.account_id, '', t.currency, 0, t.amount
+FROM journal_entries e JOIN transactions t ON e.reference_type = 'transactions' AND e.reference_id = t.id;
+
+INSERT INTO journal_entries (user_id, kind, reference_type, reference_id, description, posted_at)
+SELECT user_id, 'transfer', 'transfers', id, note, created_at FROM transfers;
+
+INSERT INTO journal_lines (entry_id, ledger, account_id, name, currency, debit, credit)
+SELECT e.id, 'asset', t.from_account_id, '', f.currency, 0, t.amount
+FROM journal_entries e
+JOIN transfers t ON e.reference_type = 'transfers' AND e.reference_id = t.id
+JOIN accounts f ON f.id = t.from_account_id
+UNION ALL
+SELECT e.id, 'exchange', NULL, '', f.currency, t.amount, 0
+FROM journal_entries e
+JOIN transfers t ON e.reference_type = 'transfers' AND e.reference_id = t.id
+JOIN accounts f ON f.id = t.from_account_id
+UNION ALL
+SELECT e.id, 'exchange', NULL, '', a.currency, 0, t.to_amount
+FROM journal_entries e
+JOIN transfers t ON e.reference_type = 'transfers' AND e.reference_id = t.id
+JOIN accounts a ON a.id = t.to_account_id
+UNION ALL
+SELECT e.id, 'asset', t.to_account_id, '', a.currency, t.to_amount, 0
+FROM journal_entries e
+JOIN transfers t ON e.reference_type = 'transfers' AND e.reference_id = t.id
+JOIN accounts a ON a.id = t.to_account_id;
+
+-- Same-currency transfers do not need the exchange ledger.
+DELETE FROM journal_lines l
+USING journal_entries e
+WHERE l.entry_id = e.id AND e.kind = 'transfer' AND l.ledger = 'exchange'
+  AND (SELECT COUNT(DISTINCT currency) FROM journal_lines x WHERE x.entry_id = e.id) = 1;
+
+-- Whatever the replayed history does not explain, such as the legacy balance
+-- the default account took over, becomes the account's opening balance.
+CREATE TEMPORARY TABLE opening_balances ON COMMIT DROP AS
+SELECT a.id AS account_id, a.user_id, a.currency, a.created_at,
+       a.balance - COALESCE((SELECT SUM(l.debit - l.credit) FROM journal_lines l WHERE l.account_id = a.id), 0) AS amount
+FROM accounts a;
+
+INSERT INTO journal_entries (user_id, kind, reference_type, reference_id, description, posted_at)
+SELECT user_id, 'opening', 'accounts', account_id, 'Opening balance', created_at
+FROM opening_balances WHERE amount <> 0;
+
+INSERT INTO journal_lines (entry_id, ledger, account_id, name, currency, debit, credit)
+SELECT e.id, 'asset', o.account_id, '', o.currency, GREATEST(o.amount, 0), GREATEST(-o.amount, 0)
+FROM journal_entries e JOIN opening_balances o ON e.reference_type = 'accounts' AND e.reference_id = o.account_id AND e.kind = 'opening'
+UNION ALL
+SELECT e.id, 'equity', NULL, 'Opening balance', o.currency, GREATEST(-o.amount, 0), GREATEST(o.amount, 0)
+FROM journal_entries e JOIN opening_balances o ON e.reference_type = 'accounts' AND e.reference_id = o.account_id AND e.kind = 'opening';
@@ -0,0 +1,15 @@
+-- Audit trail of balance corrections made by the reconciliation job.
+
+CREATE TABLE balance_reconciliations (
+    id               SERIAL PRIMARY KEY,
+    user_id          INT NOT NULL REFERENCES users (id),
+    previous_balance NUMERIC(20, 4) NOT NULL,
+    balance          NUMERIC(20, 4) NOT NULL,
+    difference       NUMERIC(20, 4) NOT NULL,
+    journal_entry_id INT REFERENCES journal_entries (id),
+    reason           TEXT NOT NULL,
+    fixed_by         INT NOT NULL REFERENCES users (id),
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX balance_reconciliations_user_idx ON balance_reconciliations (user_id);
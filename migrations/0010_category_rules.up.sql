@@ -0,0 +1,17 @@
+-- User-defined rules that categorize expenses automatically.
+
+CREATE TABLE category_rules (
+    id                   SERIAL PRIMARY KEY,
+    user_id              INT NOT NULL REFERENCES users (id),
+    name                 TEXT NOT NULL,
+    priority             INT NOT NULL DEFAULT 0,
+    description_contains TEXT NOT NULL DEFAULT '',
+    description_regex    TEXT NOT NULL DEFAULT '',
+    min_amount           NUMERIC(20, 4),
+    max_amount           NUMERIC(20, 4),
+    account_id           INT REFERENCES accounts (id) ON DELETE CASCADE,
+    category             TEXT NOT NULL,
+    created_at           TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX category_rules_user_idx ON category_rules (user_id, priority, id);
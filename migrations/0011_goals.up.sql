@@ -0,0 +1,26 @@
+-- Savings goals. A goal either tracks the balance of a linked account or
+-- counts the money allocated to it through contributions.
+
+CREATE TABLE goals (
+    id            SERIAL PRIMARY KEY,
+    user_id       INT NOT NULL REFERENCES users (id),
+    name          TEXT NOT NULL,
+    target_amount NUMERIC(20, 4) NOT NULL,
+    currency      TEXT NOT NULL,
+    deadline      DATE,
+    account_id    INT REFERENCES accounts (id),
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX goals_user_idx ON goals (user_id);
+
+CREATE TABLE goal_contributions (
+    id          SERIAL PRIMARY KEY,
+    goal_id     INT NOT NULL REFERENCES goals (id) ON DELETE CASCADE,
+    amount      NUMERIC(20, 4) NOT NULL,
+    note        TEXT NOT NULL DEFAULT '',
+    transfer_id INT REFERENCES transfers (id),
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX goal_contributions_goal_idx ON goal_contributions (goal_id);
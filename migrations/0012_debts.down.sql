@@ -0,0 +1,3 @@
+DROP TABLE debt_payments;
+DROP TABLE debts;
+DROP TABLE counterparties;
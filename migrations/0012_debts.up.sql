@@ -0,0 +1,45 @@
+-- Money lent to and borrowed from counterparties, with their repayments. The
+-- repayments themselves are booked as incomes or expenses; debt_payments
+-- records how each one splits into principal and interest.
+
+CREATE TABLE counterparties (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    name       TEXT NOT NULL,
+    note       TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX counterparties_user_name_idx ON counterparties (user_id, LOWER(name));
+
+CREATE TABLE debts (
+    id              SERIAL PRIMARY KEY,
+    user_id         INT NOT NULL REFERENCES users (id),
+    counterparty_id INT NOT NULL REFERENCES counterparties (id),
+    direction       TEXT NOT NULL CHECK (direction IN ('lent', 'borrowed')),
+    principal       NUMERIC(20, 4) NOT NULL,
+    currency        TEXT NOT NULL,
+    annual_rate     NUMERIC(9, 4) NOT NULL DEFAULT 0,
+    term_months     INT,
+    start_date      DATE NOT NULL,
+    account_id      INT NOT NULL REFERENCES accounts (id),
+    note            TEXT NOT NULL DEFAULT '',
+    closed_at       TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX debts_user_idx ON debts (user_id);
+
+CREATE TABLE debt_payments (
+    id             SERIAL PRIMARY KEY,
+    debt_id        INT NOT NULL REFERENCES debts (id),
+    amount         NUMERIC(20, 4) NOT NULL,
+    principal_part NUMERIC(20, 4) NOT NULL,
+    interest_part  NUMERIC(20, 4) NOT NULL,
+    reference_type TEXT NOT NULL,
+    reference_id   INT NOT NULL,
+    paid_at        TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX debt_payments_debt_idx ON debt_payments (debt_id);
@@ -0,0 +1,12 @@
+-- Expenses split across several categories. A transaction without splits
+-- belongs entirely to its own category.
+
+CREATE TABLE transaction_splits (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    category       TEXT NOT NULL,
+    amount         NUMERIC(20, 4) NOT NULL CHECK (amount > 0),
+    note           TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX transaction_splits_transaction_idx ON transaction_splits (transaction_id);
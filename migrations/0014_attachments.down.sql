@@ -0,0 +1,3 @@
+-- Stored objects are left in place.
+
+DROP TABLE attachments;
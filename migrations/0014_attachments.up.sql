@@ -0,0 +1,21 @@
+-- Files attached to expenses and items. The stored object is addressed by the
+-- user and the SHA-256 checksum of its content, so uploading the same file
+-- twice stores it once. Objects of attachments removed along with their
+-- expense or item stay in storage and are reused if uploaded again.
+
+CREATE TABLE attachments (
+    id             SERIAL PRIMARY KEY,
+    user_id        INT NOT NULL REFERENCES users (id),
+    transaction_id INT REFERENCES transactions (id) ON DELETE CASCADE,
+    item_id        INT REFERENCES items (id) ON DELETE CASCADE,
+    file_name      TEXT NOT NULL,
+    content_type   TEXT NOT NULL,
+    size           BIGINT NOT NULL,
+    checksum       TEXT NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((transaction_id IS NULL) <> (item_id IS NULL))
+);
+
+CREATE INDEX attachments_user_checksum_idx ON attachments (user_id, checksum);
+CREATE UNIQUE INDEX attachments_transaction_checksum_idx ON attachments (transaction_id, checksum) WHERE transaction_id IS NOT NULL;
+CREATE UNIQUE INDEX attachments_item_checksum_idx ON attachments (item_id, checksum) WHERE item_id IS NOT NULL;
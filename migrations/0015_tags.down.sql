@@ -0,0 +1,4 @@
+DROP TABLE item_tags;
+DROP TABLE income_tags;
+DROP TABLE transaction_tags;
+DROP TABLE tags;
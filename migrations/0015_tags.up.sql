@@ -0,0 +1,34 @@
+-- Free-form labels shared by expenses, incomes and items, such as
+-- "trip-bali" or "tax-deductible". Tag names are unique per user regardless
+-- of case.
+
+CREATE TABLE tags (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL REFERENCES users (id),
+    name       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX tags_user_name_idx ON tags (user_id, LOWER(name));
+
+CREATE TABLE transaction_tags (
+    transaction_id INT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    tag_id         INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE TABLE income_tags (
+    income_id INT NOT NULL REFERENCES incomes (id) ON DELETE CASCADE,
+    tag_id    INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (income_id, tag_id)
+);
+
+CREATE TABLE item_tags (
+    item_id INT NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    tag_id  INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX transaction_tags_tag_idx ON transaction_tags (tag_id);
+CREATE INDEX income_tags_tag_idx ON income_tags (tag_id);
+CREATE INDEX item_tags_tag_idx ON item_tags (tag_id);
@@ -0,0 +1,3 @@
+ALTER TABLE items DROP COLUMN search_vector;
+ALTER TABLE incomes DROP COLUMN search_vector;
+ALTER TABLE transactions DROP COLUMN search_vector;
@@ -0,0 +1,20 @@
+-- Full-text search over expenses, incomes and items. The 'simple'
+-- configuration does not stem, so searches work the same in any language.
+
+ALTER TABLE transactions ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(category, '')), 'B')
+) STORED;
+
+ALTER TABLE incomes ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', COALESCE(source, ''))
+) STORED;
+
+ALTER TABLE items ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX transactions_search_idx ON transactions USING GIN (search_vector);
+CREATE INDEX incomes_search_idx ON incomes USING GIN (search_vector);
+CREATE INDEX items_search_idx ON items USING GIN (search_vector);
@@ -0,0 +1,43 @@
+-- Times go back to the wall-clock time of the session time zone.
+
+ALTER TABLE tags ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE attachments ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE debt_payments
+    ALTER COLUMN paid_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE debts
+    ALTER COLUMN closed_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE counterparties ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE goal_contributions ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE goals ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE category_rules ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE balance_reconciliations ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE journal_entries
+    ALTER COLUMN posted_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE transfers ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE accounts ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE recurring_occurrences
+    ALTER COLUMN due_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE recurring_rules
+    ALTER COLUMN start_at TYPE TIMESTAMP,
+    ALTER COLUMN end_at TYPE TIMESTAMP,
+    ALTER COLUMN next_run_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE categories ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE budgets ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE stock_transactions ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE items ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE incomes ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE token_blacklist ALTER COLUMN created_at TYPE TIMESTAMP;
@@ -0,0 +1,47 @@
+-- Store every point in time with its time zone. The API wrote its local
+-- wall-clock time into TIMESTAMP columns, dropping the offset, so periods were
+-- shifted by the server's UTC offset wherever it did not run in UTC. Existing
+-- values are read in the session time zone: when the API did not run in the
+-- database's time zone, run this migration with PGTZ set to the API's.
+
+ALTER TABLE token_blacklist ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE incomes ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE items ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE stock_transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE budgets ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE categories ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE recurring_rules
+    ALTER COLUMN start_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN end_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN next_run_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE recurring_occurrences
+    ALTER COLUMN due_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE accounts ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE transfers ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE journal_entries
+    ALTER COLUMN posted_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE balance_reconciliations ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE category_rules ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE goals ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE goal_contributions ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE counterparties ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE debts
+    ALTER COLUMN closed_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE debt_payments
+    ALTER COLUMN paid_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE attachments ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE tags ALTER COLUMN created_at TYPE TIMESTAMPTZ;
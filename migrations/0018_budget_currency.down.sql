@@ -0,0 +1 @@
+ALTER TABLE budgets DROP COLUMN currency;
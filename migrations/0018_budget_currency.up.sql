@@ -0,0 +1,12 @@
+-- Budgets are set in one currency, and spending in other currencies is
+-- converted into it. Existing budgets take the currency of the user's default
+-- account, which is what they were checked against until now.
+
+ALTER TABLE budgets ADD COLUMN currency TEXT;
+
+UPDATE budgets b SET currency = COALESCE(
+    (SELECT a.currency FROM accounts a WHERE a.user_id = b.user_id AND a.is_default),
+    'IDR'
+);
+
+ALTER TABLE budgets ALTER COLUMN currency SET NOT NULL;
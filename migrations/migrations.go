@@ -0,0 +1,354 @@
+// Package migrations holds the versioned database schema. Each version is a
+// pair of NNNN_name.up.sql and NNNN_name.down.sql files embedded in the binary
+// and applied in order, each in its own database transaction. Applied versions
+// are recorded in the schema_migrations table, and a PostgreSQL advisory lock
+// keeps concurrent instances from migrating at the same time.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID identifies the advisory lock held while migrating.
+const lockID = 0x5ca5e
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// All returns the embedded migrations ordered by version.
+func All() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, name := range names {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", name)
+		}
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s must start with a positive version number", name)
+		}
+
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: title}
+			byVersion[version] = migration
+		} else if migration.Name != title {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, title)
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure the schema_migrations table exists.
+func withLock(db *sql.DB, fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func applied(conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// run executes one direction of a migration and records the outcome in the
+// same database transaction.
+func run(conn *sql.Conn, migration Migration, up bool) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	script, record, args := migration.Down, `DELETE FROM schema_migrations WHERE version = $1`, []interface{}{migration.Version}
+	if up {
+		script, record, args = migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, []interface{}{migration.Version, migration.Name}
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// Up applies every pending migration and returns the ones it applied.
+//
+// A database whose tables were created before migrations were versioned has
+// no migration history; Up refuses to touch it until Force records the
+// versions it already has.
+func Up(db *sql.DB) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withLock(db, func(conn *sql.Conn) error {
+		versions, err := applied(conn)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 {
+			var legacy bool
+			if err := conn.QueryRowContext(context.Background(), `SELECT to_regclass('users') IS NOT NULL`).Scan(&legacy); err != nil {
+				return fmt.Errorf("failed to inspect database: %w", err)
+			}
+			if legacy {
+				return fmt.Errorf("database has tables but no migration history, record the migrations it already has with migrate force <version>")
+			}
+		}
+
+		for _, migration := range migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := run(conn, migration, true); err != nil {
+				return err
+			}
+			slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations and returns the ones it
+// reverted, newest first.
+func Down(db *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = withLock(db, func(conn *sql.Conn) error {
+		versions, err := applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if err := run(conn, migration, false); err != nil {
+				return err
+			}
+			slog.Info("Reverted migration", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Force records the migrations up to version as applied and the newer ones as
+// not applied, without running any of them. It brings the history of a
+// database migrated by hand in line with its schema.
+func Force(db *sql.DB, version int) error {
+	migrations, err := All()
+	if err != nil {
+		return err
+	}
+
+	known := version == 0
+	for _, migration := range migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return withLock(db, func(conn *sql.Conn) error {
+		ctx := context.Background()
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start database transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version > $1`, version); err != nil {
+			return fmt.Errorf("failed to update migration history: %w", err)
+		}
+		for _, migration := range migrations {
+			if migration.Version > version {
+				break
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("failed to update migration history: %w", err)
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Pending returns the embedded migrations not applied to the database yet.
+// Unlike the other functions it does not wait for the migration lock, so it
+// can answer while another instance is migrating, and it gives up when ctx is
+// done.
+func Pending(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+	if !exists {
+		return migrations, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch applied migrations: %w", err)
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Statuses lists every embedded migration with the time it was applied, nil
+// for pending ones.
+func Statuses(db *sql.DB) ([]Status, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	err = withLock(db, func(conn *sql.Conn) error {
+		versions, err := applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
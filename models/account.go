@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+type Account struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Currency  string    `json:"currency"`
+	Balance   Money     `json:"balance"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ConvertedBalance  *Money `json:"converted_balance,omitempty"`
+	ConvertedCurrency string `json:"converted_currency,omitempty"`
+}
+
+type Transfer struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	FromAccountID int       `json:"from_account_id"`
+	ToAccountID   int       `json:"to_account_id"`
+	Amount        Money     `json:"amount"`
+	ToAmount      Money     `json:"to_amount"`
+	ExchangeRate  float64   `json:"exchange_rate"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type BalanceSummary struct {
+	Currency string    `json:"currency"`
+	Total    Money     `json:"total"`
+	Accounts []Account `json:"accounts"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is a file, such as a receipt photo, attached to an expense or an
+// item. Identical uploads of a user share one stored object, found by checksum.
+type Attachment struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	ItemID        *int      `json:"item_id,omitempty"`
+	FileName      string    `json:"file_name"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	Checksum      string    `json:"checksum"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type Budget struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Category  string    `json:"category"`
+	Amount    Money     `json:"amount"`
+	Currency  string    `json:"currency"`
+	Period    string    `json:"period"`
+	Enforce   bool      `json:"enforce"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BudgetStatus struct {
+	BudgetID    int       `json:"budget_id"`
+	Category    string    `json:"category"`
+	Currency    string    `json:"currency"`
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Budget      Money     `json:"budget"`
+	Spent       Money     `json:"spent"`
+	Remaining   Money     `json:"remaining"`
+	Exceeded    bool      `json:"exceeded"`
+	Enforce     bool      `json:"enforce"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+type Category struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind"`
+	ParentID  *int       `json:"parent_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Children  []Category `json:"children,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// CategoryRule assigns Category to expenses matching all of its conditions.
+// Rules are evaluated by ascending priority and the first match wins.
+type CategoryRule struct {
+	ID                  int       `json:"id"`
+	UserID              int       `json:"user_id"`
+	Name                string    `json:"name"`
+	Priority            int       `json:"priority"`
+	DescriptionContains string    `json:"description_contains,omitempty"`
+	DescriptionRegex    string    `json:"description_regex,omitempty"`
+	MinAmount           *Money    `json:"min_amount,omitempty"`
+	MaxAmount           *Money    `json:"max_amount,omitempty"`
+	AccountID           *int      `json:"account_id,omitempty"`
+	Category            string    `json:"category"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type CategoryRuleTest struct {
+	Rule        CategoryRule  `json:"rule"`
+	Matched     int           `json:"matched"`
+	WouldChange int           `json:"would_change"`
+	Matches     []Transaction `json:"matches"`
+}
+
+type CategoryChange struct {
+	TransactionID int    `json:"transaction_id"`
+	RuleID        int    `json:"rule_id"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+}
+
+type CategoryRuleApplication struct {
+	DryRun  bool             `json:"dry_run"`
+	Checked int              `json:"checked"`
+	Changed int              `json:"changed"`
+	Changes []CategoryChange `json:"changes"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+type Counterparty struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Debt is money lent to or borrowed from a counterparty. AnnualRate is the
+// nominal yearly interest rate in percent; without TermMonths the debt has no
+// repayment schedule.
+type Debt struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	CounterpartyID int        `json:"counterparty_id"`
+	Counterparty   string     `json:"counterparty"`
+	Direction      string     `json:"direction"`
+	Principal      Money      `json:"principal"`
+	Currency       string     `json:"currency"`
+	AnnualRate     float64    `json:"annual_rate"`
+	TermMonths     *int       `json:"term_months"`
+	StartDate      time.Time  `json:"start_date"`
+	AccountID      int        `json:"account_id"`
+	Note           string     `json:"note"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	Outstanding   Money      `json:"outstanding"`
+	PaidPrincipal Money      `json:"paid_principal"`
+	PaidInterest  Money      `json:"paid_interest"`
+	NextDueDate   *time.Time `json:"next_due_date,omitempty"`
+	NextPayment   *Money     `json:"next_payment,omitempty"`
+
+	Payments []DebtPayment       `json:"payments,omitempty"`
+	Schedule []AmortizationEntry `json:"schedule,omitempty"`
+}
+
+// DebtPayment is a repayment, booked as the income or expense named by
+// ReferenceType and ReferenceID.
+type DebtPayment struct {
+	ID            int       `json:"id"`
+	DebtID        int       `json:"debt_id"`
+	Amount        Money     `json:"amount"`
+	PrincipalPart Money     `json:"principal_part"`
+	InterestPart  Money     `json:"interest_part"`
+	ReferenceType string    `json:"reference_type"`
+	ReferenceID   int       `json:"reference_id"`
+	PaidAt        time.Time `json:"paid_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AmortizationEntry struct {
+	Number    int       `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Payment   Money     `json:"payment"`
+	Principal Money     `json:"principal"`
+	Interest  Money     `json:"interest"`
+	Balance   Money     `json:"balance"`
+}
+
+// DebtSummary totals the outstanding debts in one currency: what others owe
+// the user (Receivable) and what the user owes (Payable).
+type DebtSummary struct {
+	Currency   string `json:"currency"`
+	Receivable Money  `json:"receivable"`
+	Payable    Money  `json:"payable"`
+	Net        Money  `json:"net"`
+	OpenDebts  int    `json:"open_debts"`
+	Debts      []Debt `json:"debts"`
+}
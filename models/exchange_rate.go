@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+type ExchangeRate struct {
+	Base     string    `json:"base"`
+	Quote    string    `json:"quote"`
+	RateDate time.Time `json:"rate_date"`
+	Rate     float64   `json:"rate"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// Goal is a savings target. With an AccountID the progress is the balance of
+// that account; otherwise it is the sum of the contributions allocated to it.
+type Goal struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Name         string     `json:"name"`
+	TargetAmount Money      `json:"target_amount"`
+	Currency     string     `json:"currency"`
+	Deadline     *time.Time `json:"deadline"`
+	AccountID    *int       `json:"account_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Saved                 Money      `json:"saved"`
+	Remaining             Money      `json:"remaining"`
+	Progress              float64    `json:"progress"`
+	Achieved              bool       `json:"achieved"`
+	AverageMonthlySavings Money      `json:"average_monthly_savings"`
+	ProjectedCompletion   *time.Time `json:"projected_completion"`
+	OnTrack               *bool      `json:"on_track,omitempty"`
+
+	Contributions []GoalContribution `json:"contributions,omitempty"`
+}
+
+type GoalContribution struct {
+	ID         int       `json:"id"`
+	GoalID     int       `json:"goal_id"`
+	Amount     Money     `json:"amount"`
+	Note       string    `json:"note"`
+	TransferID *int      `json:"transfer_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GoalSuggestion struct {
+	GoalID                int        `json:"goal_id"`
+	Currency              string     `json:"currency"`
+	Remaining             Money      `json:"remaining"`
+	Deadline              *time.Time `json:"deadline"`
+	MonthsLeft            int        `json:"months_left"`
+	MonthlyContribution   Money      `json:"monthly_contribution"`
+	AverageMonthlySavings Money      `json:"average_monthly_savings"`
+	Feasible              bool       `json:"feasible"`
+	Message               string     `json:"message"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportMapping tells the CSV importer which columns hold which fields.
+// Columns are referenced by header name or, without a header, by 1-based
+// position. Either Amount (signed, negative for expenses, or unsigned with a
+// Type column) or Debit and Credit must be given.
+type ImportMapping struct {
+	Date         string `json:"date"`
+	Amount       string `json:"amount"`
+	Debit        string `json:"debit"`
+	Credit       string `json:"credit"`
+	Type         string `json:"type"`
+	Description  string `json:"description"`
+	Category     string `json:"category"`
+	DateFormat   string `json:"date_format"`
+	Delimiter    string `json:"delimiter"`
+	DecimalComma bool   `json:"decimal_comma"`
+	NoHeader     bool   `json:"no_header"`
+}
+
+// ImportRow is one parsed line of an import file. Amount is always positive;
+// Kind tells whether it is an income or an expense. Category is the income
+// source for incomes.
+type ImportRow struct {
+	Line        int       `json:"line"`
+	Kind        string    `json:"kind,omitempty"`
+	Date        time.Time `json:"date"`
+	Amount      Money     `json:"amount"`
+	Category    string    `json:"category,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Status      string    `json:"status"`
+	Errors      []string  `json:"errors,omitempty"`
+	ReferenceID int       `json:"reference_id,omitempty"`
+}
+
+type ImportResult struct {
+	Format     string      `json:"format"`
+	AccountID  int         `json:"account_id"`
+	DryRun     bool        `json:"dry_run"`
+	Committed  bool        `json:"committed"`
+	Total      int         `json:"total"`
+	Accepted   int         `json:"accepted"`
+	Duplicates int         `json:"duplicates"`
+	Invalid    int         `json:"invalid"`
+	Rows       []ImportRow `json:"rows"`
+}
@@ -11,6 +11,8 @@ type Item struct {
 	Description string    `json:"description"`
 	Stock       int       `json:"stock"`
 	CreatedAt   time.Time `json:"created_at,omitempty"`
+
+	Tags []string `json:"tags,omitempty"`
 }
 
 type StockTransaction struct {
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+type JournalEntry struct {
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	Kind          string        `json:"kind"`
+	ReferenceType string        `json:"reference_type,omitempty"`
+	ReferenceID   int           `json:"reference_id,omitempty"`
+	Description   string        `json:"description"`
+	PostedAt      time.Time     `json:"posted_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Lines         []JournalLine `json:"lines"`
+}
+
+type JournalLine struct {
+	ID        int    `json:"id,omitempty"`
+	EntryID   int    `json:"entry_id,omitempty"`
+	Ledger    string `json:"ledger"`
+	AccountID int    `json:"account_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Currency  string `json:"currency"`
+	Debit     Money  `json:"debit"`
+	Credit    Money  `json:"credit"`
+}
+
+type UnbalancedEntry struct {
+	EntryID  int    `json:"entry_id"`
+	Currency string `json:"currency"`
+	Debit    Money  `json:"debit"`
+	Credit   Money  `json:"credit"`
+}
+
+type BalanceMismatch struct {
+	UserID    int    `json:"user_id"`
+	AccountID int    `json:"account_id,omitempty"`
+	Cached    Money  `json:"cached"`
+	Journal   Money  `json:"journal"`
+	Currency  string `json:"currency,omitempty"`
+}
+
+type JournalIntegrityReport struct {
+	OK                bool              `json:"ok"`
+	CheckedAt         time.Time         `json:"checked_at"`
+	Entries           int               `json:"entries"`
+	UnbalancedEntries []UnbalancedEntry `json:"unbalanced_entries"`
+	AccountMismatches []BalanceMismatch `json:"account_mismatches"`
+	UserMismatches    []BalanceMismatch `json:"user_mismatches"`
+}
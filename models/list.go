@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// ListQuery filters, sorts and pages a list endpoint. Zero values leave a
+// criterion out or pick the list's default sort and order ("asc" or "desc");
+// a Limit of zero returns every matching row. A Cursor from a
+// previous Page continues after that page's last row and takes precedence
+// over Offset.
+type ListQuery struct {
+	From      *time.Time
+	To        *time.Time
+	MinAmount *Money
+	MaxAmount *Money
+	Category  string
+	Search    string
+	Tags      TagFilter
+
+	Sort  string
+	Order string
+
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// Page describes the page of a list that was returned.
+type Page struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	NextOffset *int   `json:"next_offset,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
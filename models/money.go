@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale is the number of decimal places a Money value keeps. It covers
+// every supported currency, the largest minor unit being three digits.
+const MoneyScale = 4
+
+const moneyFactor = 10000
+
+// Money is an exact monetary amount, stored as an integer number of
+// ten-thousandths of the currency's major unit. It marshals to a plain JSON
+// number and maps to a NUMERIC(20,4) column (see
+// migrations/0007_money_numeric.up.sql).
+type Money int64
+
+// ParseMoney parses a decimal amount such as "12", "-3.5" or "1000.25". It
+// rejects exponents, thousands separators and more than MoneyScale decimals.
+func ParseMoney(s string) (Money, error) {
+	return parseMoney(s, false)
+}
+
+// parseMoney parses a decimal string; when round is set, digits beyond
+// MoneyScale are rounded half away from zero instead of being rejected.
+func parseMoney(s string, round bool) (Money, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(text, "-") {
+		negative = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || hasPoint && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	for _, part := range []string{whole, fraction} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("invalid amount %q", s)
+			}
+		}
+	}
+
+	roundUp := false
+	if len(fraction) > MoneyScale {
+		if !round {
+			return 0, fmt.Errorf("amount %q has more than %d decimal places", s, MoneyScale)
+		}
+		roundUp = fraction[MoneyScale] >= '5'
+		fraction = fraction[:MoneyScale]
+	}
+	fraction += strings.Repeat("0", MoneyScale-len(fraction))
+
+	var units int64
+	if digits := strings.TrimLeft(whole+fraction, "0"); digits != "" {
+		var err error
+		if units, err = strconv.ParseInt(digits, 10, 64); err != nil {
+			return 0, fmt.Errorf("amount %q is out of range", s)
+		}
+	}
+	if roundUp {
+		units++
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money(units), nil
+}
+
+// String formats the amount with as many decimals as it needs, e.g. "1500",
+// "12.5" or "-0.0001".
+func (m Money) String() string {
+	units := int64(m)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(abs) <= MoneyScale {
+		abs = strings.Repeat("0", MoneyScale-len(abs)+1) + abs
+	}
+
+	whole, fraction := abs[:len(abs)-MoneyScale], strings.TrimRight(abs[len(abs)-MoneyScale:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
+// StringFixed formats the amount with exactly places decimals, rounding half
+// away from zero when places is below MoneyScale.
+func (m Money) StringFixed(places int) string {
+	rounded := m.Round(places)
+	text := rounded.String()
+	if places <= 0 {
+		return text
+	}
+	whole, fraction, _ := strings.Cut(text, ".")
+	return whole + "." + fraction + strings.Repeat("0", places-len(fraction))
+}
+
+// Round rounds the amount half away from zero to the given number of decimals.
+func (m Money) Round(places int) Money {
+	if places >= MoneyScale {
+		return m
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(math.Pow10(MoneyScale - places))
+	units := int64(m)
+	remainder := units % step
+	units -= remainder
+	if remainder*2 >= step {
+		units += step
+	} else if remainder*2 <= -step {
+		units -= step
+	}
+	return Money(units)
+}
+
+// HasPlaces reports whether the amount needs no more than places decimals.
+func (m Money) HasPlaces(places int) bool {
+	return m.Round(places) == m
+}
+
+// MulRate multiplies the amount by an exchange rate and rounds the result half
+// away from zero to MoneyScale decimals.
+func (m Money) MulRate(rate float64) Money {
+	product := new(big.Rat).SetInt64(int64(m))
+	product.Mul(product, new(big.Rat).SetFloat64(rate))
+
+	quotient, remainder := new(big.Int).QuoRem(product.Num(), product.Denom(), new(big.Int))
+	remainder.Mul(remainder, big.NewInt(2))
+	if remainder.CmpAbs(product.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+	return Money(quotient.Int64())
+}
+
+// Float64 returns the nearest float64, for ratios and charts only.
+func (m Money) Float64() float64 {
+	return float64(m) / moneyFactor
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC and legacy floating point columns.
+func (m *Money) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case int64:
+		*m = Money(value * moneyFactor)
+		return nil
+	case float64:
+		parsed, err := parseMoney(strconv.FormatFloat(value, 'f', -1, 64), true)
+		*m = parsed
+		return err
+	case []byte:
+		parsed, err := parseMoney(string(value), true)
+		*m = parsed
+		return err
+	case string:
+		parsed, err := parseMoney(value, true)
+		*m = parsed
+		return err
+	}
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+// Value implements driver.Valuer, sending the exact decimal text.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+type BalanceDiscrepancy struct {
+	UserID   int    `json:"user_id"`
+	Name     string `json:"name"`
+	Cached   Money  `json:"cached_balance"`
+	Computed Money  `json:"computed_balance"`
+	Journal  *Money `json:"journal_balance,omitempty"`
+}
+
+type BalanceReconciliation struct {
+	ID              int       `json:"id"`
+	UserID          int       `json:"user_id"`
+	PreviousBalance Money     `json:"previous_balance"`
+	Balance         Money     `json:"balance"`
+	Difference      Money     `json:"difference"`
+	JournalEntryID  *int      `json:"journal_entry_id,omitempty"`
+	Reason          string    `json:"reason"`
+	FixedBy         int       `json:"fixed_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
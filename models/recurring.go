@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type RecurringRule struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	AccountID   int        `json:"account_id"`
+	Kind        string     `json:"kind"`
+	Amount      Money      `json:"amount"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Frequency   string     `json:"frequency"`
+	Interval    int        `json:"interval"`
+	CronExpr    string     `json:"cron_expr,omitempty"`
+	StartAt     time.Time  `json:"start_at"`
+	EndAt       *time.Time `json:"end_at"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	Paused      bool       `json:"paused"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type RecurringOccurrence struct {
+	ID        int       `json:"id,omitempty"`
+	RuleID    int       `json:"rule_id"`
+	DueAt     time.Time `json:"due_at"`
+	Status    string    `json:"status"`
+	EntryID   *int      `json:"entry_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
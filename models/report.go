@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+type CashFlowPeriod struct {
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	Income        Money     `json:"income"`
+	Expense       Money     `json:"expense"`
+	Net           Money     `json:"net"`
+	SavingsRate   *float64  `json:"savings_rate"`
+	IncomeChange  *float64  `json:"income_change"`
+	ExpenseChange *float64  `json:"expense_change"`
+	NetChange     *float64  `json:"net_change"`
+}
+
+type CashFlowReport struct {
+	Currency    string           `json:"currency"`
+	Granularity string           `json:"granularity"`
+	Timezone    string           `json:"timezone"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Income      Money            `json:"income"`
+	Expense     Money            `json:"expense"`
+	Net         Money            `json:"net"`
+	SavingsRate *float64         `json:"savings_rate"`
+	Periods     []CashFlowPeriod `json:"periods"`
+}
+
+type BreakdownItem struct {
+	Name     string   `json:"name"`
+	Amount   Money    `json:"amount"`
+	Count    int      `json:"count"`
+	Share    float64  `json:"share"`
+	Previous Money    `json:"previous_amount"`
+	Change   *float64 `json:"change"`
+}
+
+type BreakdownReport struct {
+	Currency     string          `json:"currency"`
+	Timezone     string          `json:"timezone"`
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	PreviousFrom time.Time       `json:"previous_from"`
+	Expenses     []BreakdownItem `json:"expenses"`
+	Incomes      []BreakdownItem `json:"incomes"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchResult is one entry matching a search. Highlight is the matching text
+// with the matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Highlight string    `json:"highlight"`
+	Amount    *Money    `json:"amount,omitempty"`
+	Currency  string    `json:"currency,omitempty"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
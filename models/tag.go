@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+type Tag struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Usage     int       `json:"usage"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagFilter narrows a list to entries carrying the tags: any of them, or all
+// of them when MatchAll is set. An empty filter matches everything.
+type TagFilter struct {
+	Tags     []string
+	MatchAll bool
+}
+
+type TagTotal struct {
+	Tag          string `json:"tag"`
+	Expense      Money  `json:"expense"`
+	Income       Money  `json:"income"`
+	Net          Money  `json:"net"`
+	ExpenseCount int    `json:"expense_count"`
+	IncomeCount  int    `json:"income_count"`
+}
+
+type TagReport struct {
+	Currency string     `json:"currency"`
+	Timezone string     `json:"timezone"`
+	From     time.Time  `json:"from"`
+	To       time.Time  `json:"to"`
+	Tags     []TagTotal `json:"tags"`
+}
@@ -7,16 +7,35 @@ import (
 type Transaction struct {
 	ID          int       `json:"id"`
 	UserID      int       `json:"user_id"`
-	Amount      float64   `json:"amount"`
+	AccountID   int       `json:"account_id"`
+	Amount      Money     `json:"amount"`
+	Currency    string    `json:"currency"`
 	Category    string    `json:"category"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	Splits []TransactionSplit `json:"splits,omitempty"`
+	Tags   []string           `json:"tags,omitempty"`
+}
+
+// TransactionSplit is the part of an expense that belongs to one category.
+// The splits of a transaction add up to its amount.
+type TransactionSplit struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id"`
+	Category      string `json:"category"`
+	Amount        Money  `json:"amount"`
+	Note          string `json:"note"`
 }
 
 type Income struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
-	Amount    float64   `json:"amount"`
+	AccountID int       `json:"account_id"`
+	Amount    Money     `json:"amount"`
+	Currency  string    `json:"currency"`
 	Source    string    `json:"source"`
 	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+
+	Tags []string `json:"tags,omitempty"`
+}
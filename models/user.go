@@ -6,5 +6,5 @@ type User struct {
     Email    string  `json:"email"`
     Password string  `json:"password"`
     Role     string  `json:"role"`
-    Balance  float64 `json:"balance"`
+    Balance  Money   `json:"balance"`
 }
\ No newline at end of file
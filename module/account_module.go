@@ -0,0 +1,396 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+var accountTypes = []string{"cash", "bank", "ewallet", "other"}
+
+func isValidAccountType(accountType string) bool {
+	for _, t := range accountTypes {
+		if t == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+const accountColumns = `id, user_id, name, type, currency, balance, is_default, created_at`
+
+func scanAccount(row interface{ Scan(...interface{}) error }) (models.Account, error) {
+	var account models.Account
+	err := row.Scan(&account.ID, &account.UserID, &account.Name, &account.Type, &account.Currency, &account.Balance, &account.IsDefault, &account.CreatedAt)
+	return account, err
+}
+
+// ensureDefaultAccount returns the user's default account, creating it on first
+// use. The default account takes over the legacy users.balance and every
+// income and transaction recorded before accounts existed.
+func ensureDefaultAccount(db dbExecutor, userID int) (int, error) {
+	var accountID int
+	err := db.QueryRow(`SELECT id FROM accounts WHERE user_id = $1 AND is_default`, userID).Scan(&accountID)
+	if err == nil {
+		return accountID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch default account: %w", err)
+	}
+
+	// The account and its opening balance entry are created together.
+	if database, ok := db.(*sql.DB); ok {
+		return ensureDefaultAccountTx(context.Background(), database, userID)
+	}
+	if e, ok := db.(contextExecutor); ok {
+		if database, ok := e.db.(*sql.DB); ok {
+			return ensureDefaultAccountTx(e.ctx, database, userID)
+		}
+	}
+
+	var legacyBalance models.Money
+	err = db.QueryRow(`SELECT balance FROM users WHERE id = $1`, userID).Scan(&legacyBalance)
+	if err != nil {
+		return 0, fmt.Errorf("user with ID %d does not exist", userID)
+	}
+
+	err = db.QueryRow(`
+		INSERT INTO accounts (user_id, name, type, currency, balance, is_default, created_at)
+		VALUES ($1, 'Cash', 'cash', $2, 0, TRUE, $3)
+		ON CONFLICT (user_id) WHERE is_default DO NOTHING
+		RETURNING id
+	`, userID, DefaultCurrency(), time.Now()).Scan(&accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Created concurrently by another request, which also posted the opening balance.
+		err = db.QueryRow(`SELECT id FROM accounts WHERE user_id = $1 AND is_default`, userID).Scan(&accountID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch default account: %w", err)
+		}
+		return accountID, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create default account: %w", err)
+	}
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          JournalKindOpening,
+		ReferenceType: "accounts",
+		ReferenceID:   accountID,
+		Description:   "Opening balance",
+		Lines: []models.JournalLine{
+			journalLine(LedgerAsset, accountID, "", DefaultCurrency(), legacyBalance),
+			journalLine(LedgerEquity, 0, "Opening balance", DefaultCurrency(), -legacyBalance),
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to post opening balance: %w", err)
+	}
+
+	if _, err := db.Exec(`UPDATE incomes SET account_id = $1, currency = $3 WHERE user_id = $2 AND account_id IS NULL`, accountID, userID, DefaultCurrency()); err != nil {
+		return 0, fmt.Errorf("failed to assign incomes to default account: %w", err)
+	}
+	if _, err := db.Exec(`UPDATE transactions SET account_id = $1, currency = $3 WHERE user_id = $2 AND account_id IS NULL`, accountID, userID, DefaultCurrency()); err != nil {
+		return 0, fmt.Errorf("failed to assign transactions to default account: %w", err)
+	}
+
+	return accountID, nil
+}
+
+// ensureDefaultAccountTx creates the default account in a database
+// transaction of its own.
+func ensureDefaultAccountTx(ctx context.Context, database *sql.DB, userID int) (int, error) {
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	accountID, err := ensureDefaultAccount(withContext(ctx, tx), userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit default account: %w", err)
+	}
+	return accountID, nil
+}
+
+// resolveAccount returns accountID after checking that it belongs to the user,
+// or the user's default account when accountID is zero.
+func resolveAccount(db dbExecutor, userID, accountID int) (int, error) {
+	defaultID, err := ensureDefaultAccount(db, userID)
+	if err != nil {
+		return 0, err
+	}
+	if accountID == 0 {
+		return defaultID, nil
+	}
+
+	var ownerID int
+	err = db.QueryRow(`SELECT user_id FROM accounts WHERE id = $1`, accountID).Scan(&ownerID)
+	if err != nil || ownerID != userID {
+		return 0, fmt.Errorf("account %d not found or does not belong to the user", accountID)
+	}
+
+	return accountID, nil
+}
+
+// lockAccount reads an account and locks its row until the surrounding
+// database transaction ends.
+func lockAccount(db dbExecutor, accountID int) (models.Account, error) {
+	account, err := scanAccount(db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, accountID))
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to fetch account balance: %w", err)
+	}
+	return account, nil
+}
+
+func CreateAccount(ctx context.Context, userID int, name, accountType, currency string, isDefault bool) (models.Account, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Account{}, fmt.Errorf("account name cannot be empty")
+	}
+	if !isValidAccountType(accountType) {
+		return models.Account{}, fmt.Errorf("account type must be one of %s", strings.Join(accountTypes, ", "))
+	}
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	currency, err := utils.NormalizeCurrency(currency)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Account{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	if _, err := ensureDefaultAccount(db, userID); err != nil {
+		return models.Account{}, err
+	}
+
+	if isDefault {
+		if _, err := db.Exec(`UPDATE accounts SET is_default = FALSE WHERE user_id = $1 AND is_default`, userID); err != nil {
+			return models.Account{}, fmt.Errorf("failed to change default account: %w", err)
+		}
+	}
+
+	account, err := scanAccount(db.QueryRow(`
+		INSERT INTO accounts (user_id, name, type, currency, balance, is_default, created_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $6)
+		RETURNING `+accountColumns, userID, name, accountType, currency, isDefault, time.Now()))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Account{}, fmt.Errorf("account %s already exists", name)
+		}
+		return models.Account{}, fmt.Errorf("failed to create account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Account{}, fmt.Errorf("failed to commit account: %w", err)
+	}
+
+	return account, nil
+}
+
+func GetAccounts(ctx context.Context, userID int) ([]models.Account, error) {
+	if _, err := ensureDefaultAccount(withContext(ctx, config.Database), userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := config.Database.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE user_id = $1 ORDER BY is_default DESC, name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := []models.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+func UpdateAccount(ctx context.Context, accountID, userID int, name, accountType string, isDefault bool) (*models.Account, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("account name cannot be empty")
+	}
+	if !isValidAccountType(accountType) {
+		return nil, fmt.Errorf("account type must be one of %s", strings.Join(accountTypes, ", "))
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	current, err := scanAccount(db.QueryRow(`SELECT `+accountColumns+` FROM accounts WHERE id = $1 AND user_id = $2 FOR UPDATE`, accountID, userID))
+	if err != nil {
+		return nil, fmt.Errorf("account not found or does not belong to the user: %w", err)
+	}
+	if current.IsDefault && !isDefault {
+		return nil, fmt.Errorf("choose another default account instead of unsetting the current one")
+	}
+
+	if isDefault && !current.IsDefault {
+		if _, err := db.Exec(`UPDATE accounts SET is_default = FALSE WHERE user_id = $1 AND is_default`, userID); err != nil {
+			return nil, fmt.Errorf("failed to change default account: %w", err)
+		}
+	}
+
+	account, err := scanAccount(db.QueryRow(`
+		UPDATE accounts SET name = $1, type = $2, is_default = $3 WHERE id = $4
+		RETURNING `+accountColumns, name, accountType, isDefault, accountID))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("account %s already exists", name)
+		}
+		return nil, fmt.Errorf("failed to update account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// CreateTransfer moves money between two accounts of the same user. A transfer
+// only posts to asset and exchange ledgers, so the cached users.balance is
+// left untouched. Between accounts in different currencies the amount is
+// converted at the latest rate.
+func CreateTransfer(ctx context.Context, userID, fromAccountID, toAccountID int, amount models.Money, note string) (models.Transfer, error) {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	transfer, err := createTransfer(db, userID, fromAccountID, toAccountID, amount, note)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+func createTransfer(db dbExecutor, userID, fromAccountID, toAccountID int, amount models.Money, note string) (models.Transfer, error) {
+	if amount <= 0 {
+		return models.Transfer{}, fmt.Errorf("amount must be greater than zero")
+	}
+
+	var err error
+	if fromAccountID, err = resolveAccount(db, userID, fromAccountID); err != nil {
+		return models.Transfer{}, err
+	}
+	if toAccountID, err = resolveAccount(db, userID, toAccountID); err != nil {
+		return models.Transfer{}, err
+	}
+	if fromAccountID == toAccountID {
+		return models.Transfer{}, fmt.Errorf("cannot transfer to the same account")
+	}
+
+	from, err := lockAccount(db, fromAccountID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if err := validateAmount(amount, from.Currency); err != nil {
+		return models.Transfer{}, err
+	}
+	if amount > from.Balance {
+		metrics.InsufficientFunds("transfer")
+		return models.Transfer{}, fmt.Errorf("insufficient funds: available %s, required %s", from.Balance, amount)
+	}
+	to, err := lockAccount(db, toAccountID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	now := time.Now()
+	rate, err := exchangeRate(db, from.Currency, to.Currency, now)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	toAmount := roundToCurrency(amount.MulRate(rate), to.Currency)
+
+	var transfer models.Transfer
+	err = db.QueryRow(`
+		INSERT INTO transfers (user_id, from_account_id, to_account_id, amount, to_amount, exchange_rate, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, user_id, from_account_id, to_account_id, amount, to_amount, exchange_rate, note, created_at
+	`, userID, fromAccountID, toAccountID, amount, toAmount, rate, note, now).Scan(
+		&transfer.ID, &transfer.UserID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.ToAmount, &transfer.ExchangeRate, &transfer.Note, &transfer.CreatedAt,
+	)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to record transfer: %w", err)
+	}
+
+	// Across currencies each side balances against the exchange ledger in its
+	// own currency.
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          JournalKindTransfer,
+		ReferenceType: "transfers",
+		ReferenceID:   transfer.ID,
+		Description:   note,
+		PostedAt:      now,
+		Lines: []models.JournalLine{
+			journalLine(LedgerAsset, fromAccountID, "", from.Currency, -amount),
+			journalLine(LedgerExchange, 0, "", from.Currency, amount),
+			journalLine(LedgerExchange, 0, "", to.Currency, -toAmount),
+			journalLine(LedgerAsset, toAccountID, "", to.Currency, toAmount),
+		},
+	})
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to post transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+func GetTransfers(ctx context.Context, userID int) ([]models.Transfer, error) {
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT id, user_id, from_account_id, to_account_id, amount, to_amount, exchange_rate, note, created_at
+		FROM transfers WHERE user_id = $1 ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []models.Transfer{}
+	for rows.Next() {
+		var transfer models.Transfer
+		if err := rows.Scan(&transfer.ID, &transfer.UserID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.ToAmount, &transfer.ExchangeRate, &transfer.Note, &transfer.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
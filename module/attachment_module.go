@@ -0,0 +1,306 @@
+package module
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+// Attachment owners are named after their tables.
+const (
+	AttachmentOwnerTransaction = "transactions"
+	AttachmentOwnerItem        = "items"
+)
+
+// AttachmentMaxSize is the largest file accepted as an attachment.
+const AttachmentMaxSize = 10 << 20
+
+// attachmentURLLifetime is how long a signed download URL stays valid.
+const attachmentURLLifetime = 15 * time.Minute
+
+// attachmentContentTypes are the accepted file types, as sniffed from the
+// content rather than trusted from the upload.
+var attachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+var (
+	attachmentStorage     utils.Storage
+	attachmentStorageErr  error
+	attachmentStorageOnce sync.Once
+
+	attachmentDir = "attachments"
+	// attachmentSigningKey signs download URLs.
+	attachmentSigningKey []byte
+)
+
+// ConfigureAttachments sets the directory that keeps attachments when no other
+// storage backend is set, and the key that signs their download URLs.
+func ConfigureAttachments(dir, signingKey string) {
+	attachmentDir = dir
+	attachmentSigningKey = []byte(signingKey)
+}
+
+// SetAttachmentStorage replaces the storage backend for attachments. Without
+// it, attachments are kept on the local filesystem below the configured
+// directory.
+func SetAttachmentStorage(storage utils.Storage) {
+	attachmentStorageOnce.Do(func() {})
+	attachmentStorage, attachmentStorageErr = storage, nil
+}
+
+func getAttachmentStorage() (utils.Storage, error) {
+	attachmentStorageOnce.Do(func() {
+		attachmentStorage, attachmentStorageErr = utils.NewLocalStorage(attachmentDir)
+	})
+	return attachmentStorage, attachmentStorageErr
+}
+
+func attachmentOwnerColumn(ownerType string) (string, error) {
+	switch ownerType {
+	case AttachmentOwnerTransaction:
+		return "transaction_id", nil
+	case AttachmentOwnerItem:
+		return "item_id", nil
+	}
+	return "", fmt.Errorf("unknown attachment owner %q", ownerType)
+}
+
+// attachmentKey addresses the stored object by user and content so identical
+// uploads share it.
+func attachmentKey(userID int, checksum string) string {
+	return fmt.Sprintf("%d/%s/%s", userID, checksum[:2], checksum)
+}
+
+const attachmentColumns = `id, user_id, transaction_id, item_id, file_name, content_type, size, checksum, created_at`
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := row.Scan(&attachment.ID, &attachment.UserID, &attachment.TransactionID, &attachment.ItemID, &attachment.FileName,
+		&attachment.ContentType, &attachment.Size, &attachment.Checksum, &attachment.CreatedAt)
+	if err == nil {
+		attachment.DownloadURL = AttachmentDownloadURL(attachment.ID, time.Now().Add(attachmentURLLifetime))
+	}
+	return attachment, err
+}
+
+// CreateAttachment attaches a file to one of the user's expenses or items.
+// The file type is detected from its content. Uploading a file that is
+// already attached to the same owner returns the existing attachment.
+func CreateAttachment(userID int, ownerType string, ownerID int, fileName string, r io.Reader) (models.Attachment, error) {
+	column, err := attachmentOwnerColumn(ownerType)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, AttachmentMaxSize+1))
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) == 0 {
+		return models.Attachment{}, fmt.Errorf("file is empty")
+	}
+	if len(data) > AttachmentMaxSize {
+		return models.Attachment{}, fmt.Errorf("file is larger than %d MB", AttachmentMaxSize>>20)
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !attachmentContentTypes[contentType] {
+		return models.Attachment{}, fmt.Errorf("unsupported file type %s", contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	if fileName == "." || fileName == "/" {
+		fileName = "attachment"
+	}
+
+	storage, err := getAttachmentStorage()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	tx, err := config.Database.Begin()
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ownerUserID int
+	err = tx.QueryRow(`SELECT user_id FROM `+ownerType+` WHERE id = $1 FOR UPDATE`, ownerID).Scan(&ownerUserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && ownerUserID != userID) {
+		return models.Attachment{}, fmt.Errorf("%s not found or does not belong to the user", strings.TrimSuffix(ownerType, "s"))
+	}
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to fetch %s: %w", strings.TrimSuffix(ownerType, "s"), err)
+	}
+
+	existing, err := scanAttachment(tx.QueryRow(`SELECT `+attachmentColumns+` FROM attachments WHERE `+column+` = $1 AND checksum = $2`, ownerID, checksum))
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.Attachment{}, fmt.Errorf("failed to check for duplicate attachment: %w", err)
+	}
+
+	var stored bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM attachments WHERE user_id = $1 AND checksum = $2)`, userID, checksum).Scan(&stored); err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to check for duplicate attachment: %w", err)
+	}
+	if !stored {
+		if err := storage.Put(attachmentKey(userID, checksum), bytes.NewReader(data)); err != nil {
+			return models.Attachment{}, err
+		}
+	}
+
+	attachment, err := scanAttachment(tx.QueryRow(`
+		INSERT INTO attachments (user_id, `+column+`, file_name, content_type, size, checksum)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+attachmentColumns, userID, ownerID, fileName, contentType, len(data), checksum))
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to commit attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// GetAttachments lists the attachments of one of the user's expenses or items.
+func GetAttachments(userID int, ownerType string, ownerID int) ([]models.Attachment, error) {
+	column, err := attachmentOwnerColumn(ownerType)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := config.Database.Query(`SELECT `+attachmentColumns+` FROM attachments WHERE user_id = $1 AND `+column+` = $2 ORDER BY id`, userID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment removes an attachment, and the stored object once no other
+// attachment of the user shares it.
+func DeleteAttachment(attachmentID, userID int) error {
+	storage, err := getAttachmentStorage()
+	if err != nil {
+		return err
+	}
+
+	tx, err := config.Database.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var checksum string
+	err = tx.QueryRow(`DELETE FROM attachments WHERE id = $1 AND user_id = $2 RETURNING checksum`, attachmentID, userID).Scan(&checksum)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("attachment not found or does not belong to the user")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	var shared bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM attachments WHERE user_id = $1 AND checksum = $2)`, userID, checksum).Scan(&shared); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit attachment deletion: %w", err)
+	}
+
+	if !shared {
+		if err := storage.Delete(attachmentKey(userID, checksum)); err != nil {
+			slog.Warn("Failed to delete stored attachment", "checksum", checksum, "user_id", userID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func signAttachment(attachmentID int, expires int64) string {
+	mac := hmac.New(sha256.New, attachmentSigningKey)
+	fmt.Fprintf(mac, "%d:%d", attachmentID, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AttachmentDownloadURL returns a download URL for the attachment that is
+// valid until expires. Anyone holding the URL can download the file, so it is
+// only handed to the attachment's owner.
+func AttachmentDownloadURL(attachmentID int, expires time.Time) string {
+	return fmt.Sprintf("/savecash/attachments/%d/download?expires=%d&signature=%s",
+		attachmentID, expires.Unix(), signAttachment(attachmentID, expires.Unix()))
+}
+
+// OpenAttachment checks a signed download URL and opens the attachment's
+// content. The caller closes the returned reader.
+func OpenAttachment(attachmentID int, expires, signature string) (models.Attachment, io.ReadCloser, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(signAttachment(attachmentID, expiresAt))) {
+		return models.Attachment{}, nil, fmt.Errorf("invalid download signature")
+	}
+	if time.Now().Unix() > expiresAt {
+		return models.Attachment{}, nil, fmt.Errorf("download link has expired")
+	}
+
+	attachment, err := scanAttachment(config.Database.QueryRow(`SELECT `+attachmentColumns+` FROM attachments WHERE id = $1`, attachmentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Attachment{}, nil, fmt.Errorf("attachment not found")
+	}
+	if err != nil {
+		return models.Attachment{}, nil, fmt.Errorf("failed to fetch attachment: %w", err)
+	}
+
+	storage, err := getAttachmentStorage()
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	content, err := storage.Open(attachmentKey(attachment.UserID, attachment.Checksum))
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+
+	return attachment, content, nil
+}
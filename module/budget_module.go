@@ -0,0 +1,302 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+const (
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodMonthly = "monthly"
+)
+
+func isValidBudgetPeriod(period string) bool {
+	return period == BudgetPeriodWeekly || period == BudgetPeriodMonthly
+}
+
+// budgetPeriodBounds returns the [start, end) window of the period containing now.
+// Weeks start on Monday.
+func budgetPeriodBounds(period string, now time.Time) (time.Time, time.Time) {
+	year, month, day := now.Date()
+	if period == BudgetPeriodWeekly {
+		offset := (int(now.Weekday()) + 6) % 7
+		start := time.Date(year, month, day-offset, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 0, 7)
+	}
+
+	start := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+const budgetColumns = `id, user_id, category, amount, currency, period, enforce, created_at`
+
+func scanBudget(row interface{ Scan(...interface{}) error }) (models.Budget, error) {
+	var budget models.Budget
+	err := row.Scan(&budget.ID, &budget.UserID, &budget.Category, &budget.Amount, &budget.Currency, &budget.Period, &budget.Enforce, &budget.CreatedAt)
+	return budget, err
+}
+
+// CreateBudget sets a budget in currency, defaulting to the currency of the
+// user's default account. Spending in other currencies is converted into it.
+func CreateBudget(ctx context.Context, userID int, category string, amount models.Money, currency, period string, enforce bool) (models.Budget, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return models.Budget{}, fmt.Errorf("category cannot be empty")
+	}
+	if amount <= 0 {
+		return models.Budget{}, fmt.Errorf("amount must be greater than zero")
+	}
+	if !isValidBudgetPeriod(period) {
+		return models.Budget{}, fmt.Errorf("period must be either %s or %s", BudgetPeriodWeekly, BudgetPeriodMonthly)
+	}
+	currency, err := reportCurrency(withContext(ctx, config.Database), userID, currency)
+	if err != nil {
+		return models.Budget{}, err
+	}
+	if err := validateAmount(amount, currency); err != nil {
+		return models.Budget{}, err
+	}
+
+	query := `
+		INSERT INTO budgets (user_id, category, amount, currency, period, enforce, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + budgetColumns
+	budget, err := scanBudget(config.Database.QueryRowContext(ctx, query, userID, category, amount, currency, period, enforce, time.Now()))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Budget{}, fmt.Errorf("a %s budget for category %s already exists", period, category)
+		}
+		return models.Budget{}, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	return budget, nil
+}
+
+func GetBudgets(ctx context.Context, userID int) ([]models.Budget, error) {
+	return loadBudgets(withContext(ctx, config.Database), userID)
+}
+
+func loadBudgets(db dbExecutor, userID int) ([]models.Budget, error) {
+	rows, err := db.Query(`SELECT `+budgetColumns+` FROM budgets WHERE user_id = $1 ORDER BY category, period`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, nil
+}
+
+func UpdateBudget(ctx context.Context, budgetID int, userID int, amount models.Money, period string, enforce bool) (*models.Budget, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if !isValidBudgetPeriod(period) {
+		return nil, fmt.Errorf("period must be either %s or %s", BudgetPeriodWeekly, BudgetPeriodMonthly)
+	}
+
+	var currency string
+	err := config.Database.QueryRowContext(ctx, `SELECT currency FROM budgets WHERE id = $1 AND user_id = $2`, budgetID, userID).Scan(&currency)
+	if err != nil {
+		return nil, fmt.Errorf("budget not found or does not belong to the user: %w", err)
+	}
+	if err := validateAmount(amount, currency); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE budgets SET amount = $1, period = $2, enforce = $3
+		WHERE id = $4 AND user_id = $5
+		RETURNING ` + budgetColumns
+	budget, err := scanBudget(config.Database.QueryRowContext(ctx, query, amount, period, enforce, budgetID, userID))
+	if err != nil {
+		return nil, fmt.Errorf("budget not found or does not belong to the user: %w", err)
+	}
+
+	return &budget, nil
+}
+
+func DeleteBudget(ctx context.Context, budgetID int, userID int) error {
+	result, err := config.Database.ExecContext(ctx, `DELETE FROM budgets WHERE id = $1 AND user_id = $2`, budgetID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("budget not found or does not belong to the user")
+	}
+
+	return nil
+}
+
+// GetBudgetStatus reports, for every budget of the user, how much was spent in
+// the category during the budget's current period, in the budget's currency.
+func GetBudgetStatus(ctx context.Context, userID int) ([]models.BudgetStatus, error) {
+	return budgetStatus(withContext(ctx, config.Database), userID, "", time.Now())
+}
+
+// GetBudgetWarnings returns the statuses of the exceeded budgets covering category.
+func GetBudgetWarnings(ctx context.Context, userID int, category string) ([]models.BudgetStatus, error) {
+	statuses, err := budgetStatus(withContext(ctx, config.Database), userID, category, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []models.BudgetStatus{}
+	for _, status := range statuses {
+		if status.Exceeded {
+			warnings = append(warnings, status)
+		}
+	}
+
+	return warnings, nil
+}
+
+// budgetStatus reports the budgets of the user covering category (all of them
+// when it is empty) for their periods containing at.
+func budgetStatus(db dbExecutor, userID int, category string, at time.Time) ([]models.BudgetStatus, error) {
+	budgets, err := loadBudgets(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []models.BudgetStatus{}
+	for _, budget := range budgets {
+		if category != "" && !strings.EqualFold(budget.Category, strings.TrimSpace(category)) {
+			continue
+		}
+
+		start, end := budgetPeriodBounds(budget.Period, at)
+		spent, err := categorySpending(newCurrencyConverter(db, budget.Currency), userID, budget.Category, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, models.BudgetStatus{
+			BudgetID:    budget.ID,
+			Category:    budget.Category,
+			Currency:    budget.Currency,
+			Period:      budget.Period,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Budget:      budget.Amount,
+			Spent:       spent,
+			Remaining:   budget.Amount - spent,
+			Exceeded:    spent > budget.Amount,
+			Enforce:     budget.Enforce,
+		})
+	}
+
+	return statuses, nil
+}
+
+// categorySpending adds up the expenses in category between start and end in
+// the converter's currency, valuing each currency at the rate of the period as
+// reports do.
+func categorySpending(converter *currencyConverter, userID int, category string, start, end time.Time) (models.Money, error) {
+	rows, err := converter.db.Query(`
+		SELECT COALESCE(currency, $1), SUM(amount) FROM `+expenseLinesTable+` AS t
+		WHERE user_id = $2 AND LOWER(TRIM(category)) = LOWER($3) AND created_at >= $4 AND created_at < $5
+		GROUP BY 1
+	`, DefaultCurrency(), userID, category, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch spending for category %s: %w", category, err)
+	}
+	defer rows.Close()
+
+	type currencySum struct {
+		currency string
+		amount   models.Money
+	}
+	var sums []currencySum
+	for rows.Next() {
+		var sum currencySum
+		if err := rows.Scan(&sum.currency, &sum.amount); err != nil {
+			return 0, fmt.Errorf("failed to scan spending for category %s: %w", category, err)
+		}
+		sums = append(sums, sum)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to fetch spending for category %s: %w", category, err)
+	}
+	rows.Close()
+
+	var spent models.Money
+	for _, sum := range sums {
+		converted, err := converter.convert(sum.amount, sum.currency, conversionDate(end))
+		if err != nil {
+			return 0, err
+		}
+		spent += converted
+	}
+
+	return roundToCurrency(spent, converter.target), nil
+}
+
+// checkEnforcedBudgets rejects an expense of amount in currency, dated at, that
+// would push the category over an enforced budget for that date. Budgets that
+// are not enforced only produce warnings.
+func checkEnforcedBudgets(db dbExecutor, userID int, category string, amount models.Money, currency string, at time.Time) error {
+	statuses, err := budgetStatus(db, userID, category, at)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if !status.Enforce {
+			continue
+		}
+		required, err := newCurrencyConverter(db, status.Currency).convert(amount, currency, conversionDate(status.PeriodEnd))
+		if err != nil {
+			return err
+		}
+		required = roundToCurrency(required, status.Currency)
+		if status.Spent+required > status.Budget {
+			return fmt.Errorf("budget exceeded for category %s: %s budget %s %s, spent %s, required %s",
+				status.Category, status.Period, status.Budget, status.Currency, status.Spent, required)
+		}
+	}
+
+	return nil
+}
+
+// checkEnforcedBudgetsOnUpdate rejects an update of an expense in currency,
+// dated at, that would push one of its categories over an enforced budget for
+// the expense's own period. The expense already counts towards the spending,
+// so only what the update adds to a category is checked: an increase, or the
+// whole amount moved into a new category.
+func checkEnforcedBudgetsOnUpdate(db dbExecutor, userID int, currency string, at time.Time, before, after []categoryAmount) error {
+	previous := map[string]models.Money{}
+	for _, line := range before {
+		previous[strings.ToLower(strings.TrimSpace(line.category))] += line.amount
+	}
+
+	for _, line := range after {
+		added := line.amount - previous[strings.ToLower(strings.TrimSpace(line.category))]
+		if added <= 0 {
+			continue
+		}
+		if err := checkEnforcedBudgets(db, userID, line.category, added, currency, at); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
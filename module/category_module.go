@@ -0,0 +1,315 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+const (
+	CategoryKindExpense = "expense"
+	CategoryKindIncome  = "income"
+)
+
+func isValidCategoryKind(kind string) bool {
+	return kind == CategoryKindExpense || kind == CategoryKindIncome
+}
+
+func scanCategory(row interface{ Scan(...interface{}) error }) (models.Category, error) {
+	var category models.Category
+	var parentID sql.NullInt64
+	err := row.Scan(&category.ID, &category.UserID, &category.Name, &category.Kind, &parentID, &category.CreatedAt)
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		category.ParentID = &id
+	}
+	return category, err
+}
+
+func getCategory(db dbExecutor, categoryID, userID int) (models.Category, error) {
+	row := db.QueryRow(`SELECT id, user_id, name, kind, parent_id, created_at FROM categories WHERE id = $1 AND user_id = $2`, categoryID, userID)
+	category, err := scanCategory(row)
+	if err != nil {
+		return models.Category{}, fmt.Errorf("category not found or does not belong to the user: %w", err)
+	}
+	return category, nil
+}
+
+func CreateCategory(ctx context.Context, userID int, name, kind string, parentID *int) (models.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Category{}, fmt.Errorf("category name cannot be empty")
+	}
+	if !isValidCategoryKind(kind) {
+		return models.Category{}, fmt.Errorf("kind must be either %s or %s", CategoryKindExpense, CategoryKindIncome)
+	}
+
+	if parentID != nil {
+		parent, err := getCategory(withContext(ctx, config.Database), *parentID, userID)
+		if err != nil {
+			return models.Category{}, err
+		}
+		if parent.Kind != kind {
+			return models.Category{}, fmt.Errorf("parent category must be of kind %s", kind)
+		}
+	}
+
+	query := `
+		INSERT INTO categories (user_id, name, kind, parent_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, name, kind, parent_id, created_at
+	`
+	category, err := scanCategory(config.Database.QueryRowContext(ctx, query, userID, name, kind, parentID, time.Now()))
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Category{}, fmt.Errorf("%s category %s already exists", kind, name)
+		}
+		return models.Category{}, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetCategories returns the user's categories of the given kind as a tree of
+// root categories with their children nested. An empty kind returns both kinds.
+func GetCategories(ctx context.Context, userID int, kind string) ([]models.Category, error) {
+	query := `SELECT id, user_id, name, kind, parent_id, created_at FROM categories WHERE user_id = $1 AND ($2 = '' OR kind = $2) ORDER BY kind, name`
+	rows, err := config.Database.QueryContext(ctx, query, userID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	defer rows.Close()
+
+	var flat []models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		flat = append(flat, category)
+	}
+
+	return buildCategoryTree(flat, nil), nil
+}
+
+func buildCategoryTree(flat []models.Category, parentID *int) []models.Category {
+	tree := []models.Category{}
+	for _, category := range flat {
+		if (parentID == nil && category.ParentID == nil) || (parentID != nil && category.ParentID != nil && *category.ParentID == *parentID) {
+			id := category.ID
+			category.Children = buildCategoryTree(flat, &id)
+			tree = append(tree, category)
+		}
+	}
+	return tree
+}
+
+// ResolveCategory validates name against the user's taxonomy of the given kind
+// and returns the canonical spelling. Matching ignores case and surrounding
+// whitespace. Users who have not defined any category of that kind yet are not
+// restricted, so existing clients keep working until a taxonomy is set up.
+func ResolveCategory(ctx context.Context, userID int, kind, name string) (string, error) {
+	return resolveCategory(withContext(ctx, config.Database), userID, kind, name)
+}
+
+func resolveCategory(db dbExecutor, userID int, kind, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s category cannot be empty", kind)
+	}
+
+	var canonical string
+	err := db.QueryRow(`SELECT name FROM categories WHERE user_id = $1 AND kind = $2 AND LOWER(name) = LOWER($3)`, userID, kind, name).Scan(&canonical)
+	if err == nil {
+		return canonical, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to resolve category: %w", err)
+	}
+
+	var defined bool
+	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE user_id = $1 AND kind = $2)`, userID, kind).Scan(&defined)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve category: %w", err)
+	}
+	if defined {
+		return "", fmt.Errorf("unknown %s category: %s", kind, name)
+	}
+
+	return name, nil
+}
+
+// rewriteCategoryRows renames every ledger row (and budget, categorization
+// rule and recurring rule) of the user that references the category from to
+// the category to.
+func rewriteCategoryRows(db dbExecutor, userID int, kind, from, to string) error {
+	if _, err := db.Exec(`
+		UPDATE recurring_rules SET category = $1 WHERE user_id = $2 AND kind = $3 AND LOWER(TRIM(category)) = LOWER($4)
+	`, to, userID, kind, from); err != nil {
+		return fmt.Errorf("failed to rewrite recurring rules: %w", err)
+	}
+
+	if kind == CategoryKindIncome {
+		if _, err := db.Exec(`UPDATE incomes SET source = $1 WHERE user_id = $2 AND LOWER(TRIM(source)) = LOWER($3)`, to, userID, from); err != nil {
+			return fmt.Errorf("failed to rewrite incomes: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := db.Exec(`UPDATE transactions SET category = $1 WHERE user_id = $2 AND LOWER(TRIM(category)) = LOWER($3)`, to, userID, from); err != nil {
+		return fmt.Errorf("failed to rewrite transactions: %w", err)
+	}
+	if _, err := db.Exec(`
+		UPDATE transaction_splits s SET category = $1 FROM transactions t
+		WHERE s.transaction_id = t.id AND t.user_id = $2 AND LOWER(TRIM(s.category)) = LOWER($3)
+	`, to, userID, from); err != nil {
+		return fmt.Errorf("failed to rewrite transaction splits: %w", err)
+	}
+
+	// A budget already defined on the target category for the same period wins.
+	if _, err := db.Exec(`
+		DELETE FROM budgets b WHERE b.user_id = $1 AND LOWER(b.category) = LOWER($2)
+		AND EXISTS (SELECT 1 FROM budgets t WHERE t.user_id = b.user_id AND t.period = b.period AND LOWER(t.category) = LOWER($3) AND t.id <> b.id)
+	`, userID, from, to); err != nil {
+		return fmt.Errorf("failed to rewrite budgets: %w", err)
+	}
+	if _, err := db.Exec(`UPDATE budgets SET category = $1 WHERE user_id = $2 AND LOWER(category) = LOWER($3)`, to, userID, from); err != nil {
+		return fmt.Errorf("failed to rewrite budgets: %w", err)
+	}
+	if _, err := db.Exec(`UPDATE category_rules SET category = $1 WHERE user_id = $2 AND LOWER(TRIM(category)) = LOWER($3)`, to, userID, from); err != nil {
+		return fmt.Errorf("failed to rewrite category rules: %w", err)
+	}
+
+	return nil
+}
+
+func RenameCategory(ctx context.Context, categoryID, userID int, name string) (*models.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("category name cannot be empty")
+	}
+
+	category, err := getCategory(withContext(ctx, config.Database), categoryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	_, err = db.Exec(`UPDATE categories SET name = $1 WHERE id = $2`, name, categoryID)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("%s category %s already exists, merge the categories instead", category.Kind, name)
+		}
+		return nil, fmt.Errorf("failed to rename category: %w", err)
+	}
+
+	if err := rewriteCategoryRows(db, userID, category.Kind, category.Name, name); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit category rename: %w", err)
+	}
+
+	category.Name = name
+	return &category, nil
+}
+
+// isCategoryAncestor reports whether the category ancestorID is above category
+// in the tree. It refuses to walk a tree that already loops.
+func isCategoryAncestor(db dbExecutor, ancestorID int, category models.Category) (bool, error) {
+	seen := map[int]bool{category.ID: true}
+	parentID := category.ParentID
+	for parentID != nil {
+		if *parentID == ancestorID {
+			return true, nil
+		}
+		if seen[*parentID] {
+			return false, fmt.Errorf("category tree of category %d contains a loop", category.ID)
+		}
+		seen[*parentID] = true
+
+		var next sql.NullInt64
+		if err := db.QueryRow(`SELECT parent_id FROM categories WHERE id = $1`, *parentID).Scan(&next); err != nil {
+			return false, fmt.Errorf("failed to walk category tree: %w", err)
+		}
+		parentID = nil
+		if next.Valid {
+			id := int(next.Int64)
+			parentID = &id
+		}
+	}
+	return false, nil
+}
+
+// MergeCategory folds the source category into the target: ledger rows and
+// budgets are rewritten to the target name, children are moved under the
+// target and the source category is deleted.
+func MergeCategory(ctx context.Context, sourceID, targetID, userID int) (*models.Category, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a category into itself")
+	}
+
+	source, err := getCategory(withContext(ctx, config.Database), sourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := getCategory(withContext(ctx, config.Database), targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if source.Kind != target.Kind {
+		return nil, fmt.Errorf("cannot merge a %s category into a %s category", source.Kind, target.Kind)
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	if err := rewriteCategoryRows(db, userID, source.Kind, source.Name, target.Name); err != nil {
+		return nil, err
+	}
+
+	// A target anywhere below the source first takes the source's place, or
+	// moving the source's children under it would close a loop through the
+	// branch leading to it.
+	below, err := isCategoryAncestor(db, source.ID, target)
+	if err != nil {
+		return nil, err
+	}
+	if below {
+		if _, err := db.Exec(`UPDATE categories SET parent_id = $1 WHERE id = $2`, source.ParentID, target.ID); err != nil {
+			return nil, fmt.Errorf("failed to reparent category: %w", err)
+		}
+		target.ParentID = source.ParentID
+	}
+
+	if _, err := db.Exec(`UPDATE categories SET parent_id = $1 WHERE parent_id = $2 AND id <> $1`, target.ID, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to reparent categories: %w", err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM categories WHERE id = $1`, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete merged category: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit category merge: %w", err)
+	}
+
+	return &target, nil
+}
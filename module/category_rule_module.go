@@ -0,0 +1,364 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+// categoryRuleTestLimit caps the matches listed by TestCategoryRule; the
+// counts always cover every transaction.
+const categoryRuleTestLimit = 100
+
+const categoryRuleColumns = `id, user_id, name, priority, description_contains, description_regex, min_amount, max_amount, account_id, category, created_at`
+
+func scanCategoryRule(row interface{ Scan(...interface{}) error }) (models.CategoryRule, error) {
+	var rule models.CategoryRule
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.Name, &rule.Priority, &rule.DescriptionContains, &rule.DescriptionRegex,
+		&rule.MinAmount, &rule.MaxAmount, &rule.AccountID, &rule.Category, &rule.CreatedAt)
+	return rule, err
+}
+
+// categoryRuleMatcher is a rule with its regular expression compiled.
+type categoryRuleMatcher struct {
+	rule  models.CategoryRule
+	regex *regexp.Regexp
+}
+
+func newCategoryRuleMatcher(rule models.CategoryRule) (categoryRuleMatcher, error) {
+	matcher := categoryRuleMatcher{rule: rule}
+	if rule.DescriptionRegex != "" {
+		regex, err := regexp.Compile("(?i)" + rule.DescriptionRegex)
+		if err != nil {
+			return categoryRuleMatcher{}, fmt.Errorf("invalid description regex: %v", err)
+		}
+		matcher.regex = regex
+	}
+	return matcher, nil
+}
+
+func (m categoryRuleMatcher) matches(accountID int, amount models.Money, description string) bool {
+	rule := m.rule
+	if rule.DescriptionContains != "" && !strings.Contains(strings.ToLower(description), strings.ToLower(rule.DescriptionContains)) {
+		return false
+	}
+	if m.regex != nil && !m.regex.MatchString(description) {
+		return false
+	}
+	if rule.MinAmount != nil && amount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && amount > *rule.MaxAmount {
+		return false
+	}
+	if rule.AccountID != nil && accountID != *rule.AccountID {
+		return false
+	}
+	return true
+}
+
+// validateCategoryRule checks the conditions of a rule and resolves its
+// category to the canonical name.
+func validateCategoryRule(userID int, rule *models.CategoryRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("rule name cannot be empty")
+	}
+	if rule.DescriptionContains == "" && rule.DescriptionRegex == "" && rule.MinAmount == nil && rule.MaxAmount == nil && rule.AccountID == nil {
+		return fmt.Errorf("rule must have at least one condition")
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return fmt.Errorf("min_amount must not be greater than max_amount")
+	}
+	if _, err := newCategoryRuleMatcher(*rule); err != nil {
+		return err
+	}
+	if rule.AccountID != nil {
+		var ownerID int
+		err := config.Database.QueryRow(`SELECT user_id FROM accounts WHERE id = $1`, *rule.AccountID).Scan(&ownerID)
+		if err != nil || ownerID != userID {
+			return fmt.Errorf("account %d not found or does not belong to the user", *rule.AccountID)
+		}
+	}
+
+	category, err := resolveCategory(config.Database, userID, CategoryKindExpense, rule.Category)
+	if err != nil {
+		return err
+	}
+	rule.Category = category
+	return nil
+}
+
+func CreateCategoryRule(userID int, rule models.CategoryRule) (models.CategoryRule, error) {
+	if err := validateCategoryRule(userID, &rule); err != nil {
+		return models.CategoryRule{}, err
+	}
+
+	query := `
+		INSERT INTO category_rules (user_id, name, priority, description_contains, description_regex, min_amount, max_amount, account_id, category, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + categoryRuleColumns
+	created, err := scanCategoryRule(config.Database.QueryRow(query, userID, rule.Name, rule.Priority, rule.DescriptionContains, rule.DescriptionRegex,
+		rule.MinAmount, rule.MaxAmount, rule.AccountID, rule.Category, time.Now()))
+	if err != nil {
+		return models.CategoryRule{}, fmt.Errorf("failed to create category rule: %w", err)
+	}
+
+	return created, nil
+}
+
+func GetCategoryRules(userID int) ([]models.CategoryRule, error) {
+	rows, err := config.Database.Query(`SELECT `+categoryRuleColumns+` FROM category_rules WHERE user_id = $1 ORDER BY priority, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.CategoryRule{}
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch category rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func UpdateCategoryRule(ruleID, userID int, rule models.CategoryRule) (*models.CategoryRule, error) {
+	if err := validateCategoryRule(userID, &rule); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE category_rules SET name = $1, priority = $2, description_contains = $3, description_regex = $4,
+		min_amount = $5, max_amount = $6, account_id = $7, category = $8
+		WHERE id = $9 AND user_id = $10
+		RETURNING ` + categoryRuleColumns
+	updated, err := scanCategoryRule(config.Database.QueryRow(query, rule.Name, rule.Priority, rule.DescriptionContains, rule.DescriptionRegex,
+		rule.MinAmount, rule.MaxAmount, rule.AccountID, rule.Category, ruleID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("category rule not found or does not belong to the user")
+		}
+		return nil, fmt.Errorf("failed to update category rule: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func DeleteCategoryRule(ruleID, userID int) error {
+	result, err := config.Database.Exec(`DELETE FROM category_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete category rule: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete category rule: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("category rule not found or does not belong to the user")
+	}
+
+	return nil
+}
+
+// loadCategoryRules returns the user's rules in evaluation order. A stored
+// rule whose regex no longer compiles is skipped rather than blocking every
+// new expense.
+func loadCategoryRules(db dbExecutor, userID int) ([]categoryRuleMatcher, error) {
+	rows, err := db.Query(`SELECT `+categoryRuleColumns+` FROM category_rules WHERE user_id = $1 ORDER BY priority, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category rules: %w", err)
+	}
+	defer rows.Close()
+
+	var matchers []categoryRuleMatcher
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category rule: %w", err)
+		}
+		matcher, err := newCategoryRuleMatcher(rule)
+		if err != nil {
+			continue
+		}
+		matchers = append(matchers, matcher)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch category rules: %w", err)
+	}
+
+	return matchers, nil
+}
+
+// matchCategoryRule returns the first rule matching the expense.
+func matchCategoryRule(matchers []categoryRuleMatcher, accountID int, amount models.Money, description string) (models.CategoryRule, bool) {
+	for _, matcher := range matchers {
+		if matcher.matches(accountID, amount, description) {
+			return matcher.rule, true
+		}
+	}
+	return models.CategoryRule{}, false
+}
+
+// CategorizeTransaction returns the category the user's rules assign to a new
+// expense, or an empty string when no rule matches. An accountID of zero
+// stands for the default account.
+func CategorizeTransaction(userID, accountID int, amount models.Money, description string) (string, error) {
+	accountID, err := resolveAccount(config.Database, userID, accountID)
+	if err != nil {
+		return "", err
+	}
+
+	matchers, err := loadCategoryRules(config.Database, userID)
+	if err != nil {
+		return "", err
+	}
+
+	rule, ok := matchCategoryRule(matchers, accountID, amount, description)
+	if !ok {
+		return "", nil
+	}
+	return rule.Category, nil
+}
+
+// TestCategoryRule shows which of the user's existing expenses an unsaved rule
+// would match, and how many of them would change category.
+func TestCategoryRule(userID int, rule models.CategoryRule) (models.CategoryRuleTest, error) {
+	if err := validateCategoryRule(userID, &rule); err != nil {
+		return models.CategoryRuleTest{}, err
+	}
+	matcher, err := newCategoryRuleMatcher(rule)
+	if err != nil {
+		return models.CategoryRuleTest{}, err
+	}
+
+	result := models.CategoryRuleTest{Rule: rule, Matches: []models.Transaction{}}
+	err = StreamTransactions(context.Background(), userID, models.ListQuery{}, func(t models.Transaction) error {
+		if !matcher.matches(t.AccountID, t.Amount, t.Description) {
+			return nil
+		}
+		result.Matched++
+		if !strings.EqualFold(strings.TrimSpace(t.Category), rule.Category) {
+			result.WouldChange++
+		}
+		if len(result.Matches) < categoryRuleTestLimit {
+			result.Matches = append(result.Matches, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.CategoryRuleTest{}, err
+	}
+
+	return result, nil
+}
+
+// ApplyCategoryRules re-runs the user's rules over every existing expense and
+// moves the matching ones to the category of their first matching rule.
+// Expenses no rule matches keep their category, and split expenses are left
+// alone. Each move is posted to the journal as an adjustment between the two
+// expense categories.
+func ApplyCategoryRules(userID int, dryRun bool) (models.CategoryRuleApplication, error) {
+	tx, err := config.Database.Begin()
+	if err != nil {
+		return models.CategoryRuleApplication{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	matchers, err := loadCategoryRules(tx, userID)
+	if err != nil {
+		return models.CategoryRuleApplication{}, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, account_id, amount, COALESCE(currency, $2), category, description FROM transactions
+		WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = transactions.id)
+		ORDER BY id FOR UPDATE
+	`, userID, DefaultCurrency())
+	if err != nil {
+		return models.CategoryRuleApplication{}, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	type move struct {
+		change   models.CategoryChange
+		amount   models.Money
+		currency string
+	}
+	result := models.CategoryRuleApplication{DryRun: dryRun, Changes: []models.CategoryChange{}}
+	var moves []move
+	for rows.Next() {
+		var id int
+		var accountID sql.NullInt64
+		var amount models.Money
+		var currency, category, description string
+		if err := rows.Scan(&id, &accountID, &amount, &currency, &category, &description); err != nil {
+			rows.Close()
+			return models.CategoryRuleApplication{}, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+
+		result.Checked++
+		rule, ok := matchCategoryRule(matchers, int(accountID.Int64), amount, description)
+		if !ok || strings.EqualFold(strings.TrimSpace(category), rule.Category) {
+			continue
+		}
+		moves = append(moves, move{
+			change:   models.CategoryChange{TransactionID: id, RuleID: rule.ID, From: category, To: rule.Category},
+			amount:   amount,
+			currency: currency,
+		})
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return models.CategoryRuleApplication{}, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	for _, m := range moves {
+		result.Changes = append(result.Changes, m.change)
+		if dryRun {
+			continue
+		}
+
+		if _, err := tx.Exec(`UPDATE transactions SET category = $1 WHERE id = $2`, m.change.To, m.change.TransactionID); err != nil {
+			return models.CategoryRuleApplication{}, fmt.Errorf("failed to recategorize transaction %d: %w", m.change.TransactionID, err)
+		}
+		_, err := postJournalEntry(tx, models.JournalEntry{
+			UserID:        userID,
+			Kind:          JournalKindAdjustment,
+			ReferenceType: "transactions",
+			ReferenceID:   m.change.TransactionID,
+			Description:   fmt.Sprintf("Recategorized from %s to %s", m.change.From, m.change.To),
+			Lines: []models.JournalLine{
+				journalLine(LedgerExpense, 0, m.change.From, m.currency, -m.amount),
+				journalLine(LedgerExpense, 0, m.change.To, m.currency, m.amount),
+			},
+		})
+		if err != nil {
+			return models.CategoryRuleApplication{}, fmt.Errorf("failed to post recategorization: %w", err)
+		}
+	}
+	result.Changed = len(result.Changes)
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return models.CategoryRuleApplication{}, fmt.Errorf("failed to commit recategorization: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,344 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+var defaultCurrency = "IDR"
+
+// SetDefaultCurrency sets the currency returned by DefaultCurrency.
+func SetDefaultCurrency(currency string) error {
+	normalized, err := utils.NormalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	defaultCurrency = normalized
+	return nil
+}
+
+// DefaultCurrency is the currency of accounts created without an explicit one,
+// including the default account that takes over the legacy users.balance.
+func DefaultCurrency() string {
+	return defaultCurrency
+}
+
+// GetReportingCurrency returns the currency of the user's default account, used
+// when a balance is requested without an explicit reporting currency.
+func GetReportingCurrency(ctx context.Context, userID int) (string, error) {
+	return reportingCurrency(withContext(ctx, config.Database), userID)
+}
+
+func reportingCurrency(db dbExecutor, userID int) (string, error) {
+	accountID, err := ensureDefaultAccount(db, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var currency string
+	if err := db.QueryRow(`SELECT currency FROM accounts WHERE id = $1`, accountID).Scan(&currency); err != nil {
+		return "", fmt.Errorf("failed to fetch default account currency: %w", err)
+	}
+	return currency, nil
+}
+
+// validateAmount rejects amounts with more decimals than the minor unit of
+// currency allows, such as 10.5 JPY or 1.001 USD.
+func validateAmount(amount models.Money, currency string) error {
+	exponent, ok := utils.CurrencyExponent(currency)
+	if !ok {
+		return nil
+	}
+	if !amount.HasPlaces(exponent) {
+		return fmt.Errorf("amount %s has more decimal places than %s allows (%d)", amount, currency, exponent)
+	}
+	return nil
+}
+
+// roundToCurrency rounds a converted amount to the minor unit of currency.
+func roundToCurrency(amount models.Money, currency string) models.Money {
+	if exponent, ok := utils.CurrencyExponent(currency); ok {
+		return amount.Round(exponent)
+	}
+	return amount
+}
+
+// LoadExchangeRates reads rates in CSV form with the header
+// "date,base,quote,rate" (date as YYYY-MM-DD, one unit of base = rate quote)
+// and upserts them into the exchange_rates table.
+func LoadExchangeRates(ctx context.Context, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read exchange rate header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return 0, fmt.Errorf("exchange rate file is missing the %s column", name)
+		}
+	}
+
+	var rates []models.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rateDate, err := time.Parse("2006-01-02", strings.TrimSpace(record[columns["date"]]))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid date: %w", line, err)
+		}
+		base, err := utils.NormalizeCurrency(record[columns["base"]])
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		quote, err := utils.NormalizeCurrency(record[columns["quote"]])
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[columns["rate"]]), 64)
+		if err != nil || rate <= 0 {
+			return 0, fmt.Errorf("line %d: rate must be a positive number", line)
+		}
+		if base == quote {
+			return 0, fmt.Errorf("line %d: base and quote currency are the same", line)
+		}
+
+		rates = append(rates, models.ExchangeRate{Base: base, Quote: quote, RateDate: rateDate, Rate: rate})
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO exchange_rates (base, quote, rate_date, rate) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (base, quote, rate_date) DO UPDATE SET rate = EXCLUDED.rate
+		`, rate.Base, rate.Quote, rate.RateDate, rate.Rate)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store exchange rate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit exchange rates: %w", err)
+	}
+
+	return len(rates), nil
+}
+
+// LoadExchangeRatesFile loads the CSV exchange rate file at path.
+func LoadExchangeRatesFile(ctx context.Context, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open exchange rate file: %w", err)
+	}
+	defer file.Close()
+
+	return LoadExchangeRates(ctx, file)
+}
+
+func GetExchangeRates(ctx context.Context, base, quote string) ([]models.ExchangeRate, error) {
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT base, quote, rate_date, rate FROM exchange_rates
+		WHERE ($1 = '' OR base = $1) AND ($2 = '' OR quote = $2)
+		ORDER BY rate_date DESC, base, quote
+		LIMIT 1000
+	`, base, quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		if err := rows.Scan(&rate.Base, &rate.Quote, &rate.RateDate, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// exchangeRate returns how many units of to one unit of from was worth on
+// date, using the most recent rate published on or before that date. Besides a
+// direct rate it accepts the inverse pair or a cross rate through a common base.
+func exchangeRate(db dbExecutor, from, to string, date time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	var rate float64
+	err := db.QueryRow(`
+		SELECT rate FROM exchange_rates WHERE base = $1 AND quote = $2 AND rate_date <= $3
+		ORDER BY rate_date DESC LIMIT 1
+	`, from, to, date).Scan(&rate)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+
+	err = db.QueryRow(`
+		SELECT rate FROM exchange_rates WHERE base = $1 AND quote = $2 AND rate_date <= $3
+		ORDER BY rate_date DESC LIMIT 1
+	`, to, from, date).Scan(&rate)
+	if err == nil {
+		return 1 / rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+
+	var fromRate, toRate float64
+	err = db.QueryRow(`
+		SELECT f.rate, t.rate FROM exchange_rates f
+		JOIN exchange_rates t ON t.base = f.base AND t.quote = $2 AND t.rate_date <= $3
+		WHERE f.quote = $1 AND f.rate_date <= $3
+		ORDER BY LEAST(f.rate_date, t.rate_date) DESC, f.rate_date DESC, t.rate_date DESC
+		LIMIT 1
+	`, from, to, date).Scan(&fromRate, &toRate)
+	if err == nil {
+		return toRate / fromRate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+
+	return 0, fmt.Errorf("no exchange rate from %s to %s on or before %s", from, to, date.Format("2006-01-02"))
+}
+
+// currencyConverter converts amounts into one reporting currency, caching the
+// rate of each currency and day it has looked up.
+type currencyConverter struct {
+	db     dbExecutor
+	target string
+	rates  map[string]float64
+}
+
+func newCurrencyConverter(db dbExecutor, target string) *currencyConverter {
+	return &currencyConverter{db: db, target: target, rates: map[string]float64{}}
+}
+
+func (c *currencyConverter) convert(amount models.Money, currency string, date time.Time) (models.Money, error) {
+	if currency == "" || currency == c.target {
+		return amount, nil
+	}
+
+	key := currency + date.Format("2006-01-02")
+	rate, ok := c.rates[key]
+	if !ok {
+		var err error
+		if rate, err = exchangeRate(c.db, currency, c.target, date); err != nil {
+			return 0, err
+		}
+		c.rates[key] = rate
+	}
+
+	return amount.MulRate(rate), nil
+}
+
+// convertAccountBalance expresses the balance of an account in the target
+// currency. Every income, expense and transfer on the account is converted at
+// the rate of its own date; any remainder not explained by entries (such as a
+// legacy opening balance) is converted at the latest rate.
+func convertAccountBalance(converter *currencyConverter, account models.Account) (models.Money, error) {
+	if account.Currency == converter.target {
+		return account.Balance, nil
+	}
+
+	rows, err := converter.db.Query(`
+		SELECT amount, created_at FROM incomes WHERE account_id = $1
+		UNION ALL SELECT -amount, created_at FROM transactions WHERE account_id = $1
+		UNION ALL SELECT -amount, created_at FROM transfers WHERE from_account_id = $1
+		UNION ALL SELECT to_amount, created_at FROM transfers WHERE to_account_id = $1
+	`, account.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch account entries: %w", err)
+	}
+	defer rows.Close()
+
+	var converted, explained models.Money
+	for rows.Next() {
+		var amount models.Money
+		var createdAt time.Time
+		if err := rows.Scan(&amount, &createdAt); err != nil {
+			return 0, fmt.Errorf("failed to scan account entry: %w", err)
+		}
+		value, err := converter.convert(amount, account.Currency, createdAt)
+		if err != nil {
+			return 0, err
+		}
+		converted += value
+		explained += amount
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to fetch account entries: %w", err)
+	}
+
+	if remainder := account.Balance - explained; remainder != 0 {
+		value, err := converter.convert(remainder, account.Currency, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		converted += value
+	}
+
+	return roundToCurrency(converted, converter.target), nil
+}
+
+// GetBalanceSummary returns the user's accounts with their balances also
+// expressed in the reporting currency, and the converted total.
+func GetBalanceSummary(ctx context.Context, userID int, currency string) (models.BalanceSummary, error) {
+	currency, err := utils.NormalizeCurrency(currency)
+	if err != nil {
+		return models.BalanceSummary{}, err
+	}
+
+	accounts, err := GetAccounts(ctx, userID)
+	if err != nil {
+		return models.BalanceSummary{}, err
+	}
+
+	summary := models.BalanceSummary{Currency: currency, Accounts: accounts}
+	converter := newCurrencyConverter(withContext(ctx, config.Database), currency)
+	for i := range accounts {
+		converted, err := convertAccountBalance(converter, accounts[i])
+		if err != nil {
+			return models.BalanceSummary{}, err
+		}
+		accounts[i].ConvertedBalance = &converted
+		accounts[i].ConvertedCurrency = currency
+		summary.Total += converted
+	}
+
+	return summary, nil
+}
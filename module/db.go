@@ -0,0 +1,62 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+)
+
+// dbExecutor is satisfied by both *sql.DB and *sql.Tx so ledger writes can run
+// standalone or as part of a larger database transaction.
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// contextDB is the context-aware side of *sql.DB and *sql.Tx.
+type contextDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// contextExecutor runs every statement with the context of the request it
+// serves, so that the queries are cancelled with it and traced under it.
+type contextExecutor struct {
+	ctx context.Context
+	db  contextDB
+}
+
+// withContext binds db to ctx for the helpers taking a dbExecutor.
+func withContext(ctx context.Context, db contextDB) dbExecutor {
+	return contextExecutor{ctx: ctx, db: db}
+}
+
+func (e contextExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return e.db.ExecContext(e.ctx, query, args...)
+}
+
+func (e contextExecutor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return e.db.QueryContext(e.ctx, query, args...)
+}
+
+func (e contextExecutor) QueryRow(query string, args ...interface{}) *sql.Row {
+	return e.db.QueryRowContext(e.ctx, query, args...)
+}
+
+// executorContext returns the context db is bound to, for logging from the
+// helpers taking a dbExecutor.
+func executorContext(db dbExecutor) context.Context {
+	if e, ok := db.(contextExecutor); ok {
+		return e.ctx
+	}
+	return context.Background()
+}
+
+// nullableID maps the zero ID used for "not set" to SQL NULL.
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
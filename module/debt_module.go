@@ -0,0 +1,532 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+const (
+	DebtDirectionLent     = "lent"
+	DebtDirectionBorrowed = "borrowed"
+)
+
+// Default categories for the incomes and expenses that debts book.
+const (
+	DefaultDebtRepaymentCategory = "Debt repayment"
+	DefaultLoanGivenCategory     = "Loans given"
+	DefaultLoanReceivedSource    = "Loans received"
+)
+
+const maxDebtTermMonths = 600
+
+const debtColumns = `d.id, d.user_id, d.counterparty_id, c.name, d.direction, d.principal, d.currency, d.annual_rate, d.term_months,
+	d.start_date, d.account_id, d.note, d.closed_at, d.created_at
+	FROM debts d JOIN counterparties c ON c.id = d.counterparty_id`
+
+func scanDebt(row interface{ Scan(...interface{}) error }) (models.Debt, error) {
+	var debt models.Debt
+	err := row.Scan(&debt.ID, &debt.UserID, &debt.CounterpartyID, &debt.Counterparty, &debt.Direction, &debt.Principal, &debt.Currency,
+		&debt.AnnualRate, &debt.TermMonths, &debt.StartDate, &debt.AccountID, &debt.Note, &debt.ClosedAt, &debt.CreatedAt)
+	return debt, err
+}
+
+func CreateCounterparty(userID int, name, note string) (models.Counterparty, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Counterparty{}, fmt.Errorf("counterparty name cannot be empty")
+	}
+
+	var counterparty models.Counterparty
+	err := config.Database.QueryRow(`
+		INSERT INTO counterparties (user_id, name, note, created_at) VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, name, note, created_at
+	`, userID, name, note, time.Now()).Scan(&counterparty.ID, &counterparty.UserID, &counterparty.Name, &counterparty.Note, &counterparty.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Counterparty{}, fmt.Errorf("counterparty %s already exists", name)
+		}
+		return models.Counterparty{}, fmt.Errorf("failed to create counterparty: %w", err)
+	}
+
+	return counterparty, nil
+}
+
+func GetCounterparties(userID int) ([]models.Counterparty, error) {
+	rows, err := config.Database.Query(`SELECT id, user_id, name, note, created_at FROM counterparties WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch counterparties: %w", err)
+	}
+	defer rows.Close()
+
+	counterparties := []models.Counterparty{}
+	for rows.Next() {
+		var counterparty models.Counterparty
+		if err := rows.Scan(&counterparty.ID, &counterparty.UserID, &counterparty.Name, &counterparty.Note, &counterparty.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan counterparty: %w", err)
+		}
+		counterparties = append(counterparties, counterparty)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch counterparties: %w", err)
+	}
+
+	return counterparties, nil
+}
+
+// resolveCounterparty returns the ID of the user's counterparty, by ID or by
+// name, creating a counterparty for a name seen for the first time.
+func resolveCounterparty(db dbExecutor, userID, counterpartyID int, name string) (int, string, error) {
+	if counterpartyID != 0 {
+		err := db.QueryRow(`SELECT name FROM counterparties WHERE id = $1 AND user_id = $2`, counterpartyID, userID).Scan(&name)
+		if err != nil {
+			return 0, "", fmt.Errorf("counterparty %d not found or does not belong to the user", counterpartyID)
+		}
+		return counterpartyID, name, nil
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, "", fmt.Errorf("a counterparty is required")
+	}
+	if _, err := db.Exec(`
+		INSERT INTO counterparties (user_id, name, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, LOWER(name)) DO NOTHING
+	`, userID, name, time.Now()); err != nil {
+		return 0, "", fmt.Errorf("failed to create counterparty: %w", err)
+	}
+	err := db.QueryRow(`SELECT id, name FROM counterparties WHERE user_id = $1 AND LOWER(name) = LOWER($2)`, userID, name).Scan(&counterpartyID, &name)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to fetch counterparty: %w", err)
+	}
+	return counterpartyID, name, nil
+}
+
+// AmortizationSchedule splits a loan into equal monthly payments (an annuity),
+// each paying the interest accrued on the remaining balance and the rest off
+// the principal. Amounts are rounded to the currency and the last payment
+// absorbs the rounding so that the balance ends at zero.
+func AmortizationSchedule(principal models.Money, annualRate float64, months int, start time.Time, currency string) []models.AmortizationEntry {
+	if months <= 0 || principal <= 0 {
+		return []models.AmortizationEntry{}
+	}
+
+	monthlyRate := annualRate / 100 / 12
+	var payment models.Money
+	if monthlyRate == 0 {
+		n := models.Money(months)
+		payment = ceilToCurrency((principal+n-1)/n, currency)
+	} else {
+		payment = roundToCurrency(principal.MulRate(monthlyRate/(1-math.Pow(1+monthlyRate, -float64(months)))), currency)
+	}
+
+	schedule := make([]models.AmortizationEntry, 0, months)
+	balance := principal
+	for number := 1; number <= months && balance > 0; number++ {
+		interest := roundToCurrency(balance.MulRate(monthlyRate), currency)
+		principalPart := payment - interest
+		if number == months || principalPart > balance {
+			principalPart = balance
+		}
+		balance -= principalPart
+
+		schedule = append(schedule, models.AmortizationEntry{
+			Number:    number,
+			DueDate:   start.AddDate(0, number, 0),
+			Payment:   principalPart + interest,
+			Principal: principalPart,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+
+	return schedule
+}
+
+// CreateDebt records money lent to or borrowed from a counterparty, named by
+// CounterpartyID or by Counterparty (created when new). The debt is held in
+// the currency of its account. With recordDisbursement the money changing
+// hands is booked as well: an expense when lending, an income when
+// borrowing.
+func CreateDebt(ctx context.Context, userID int, input models.Debt, recordDisbursement bool) (models.Debt, error) {
+	if input.Direction != DebtDirectionLent && input.Direction != DebtDirectionBorrowed {
+		return models.Debt{}, fmt.Errorf("direction must be either %s or %s", DebtDirectionLent, DebtDirectionBorrowed)
+	}
+	if input.Principal <= 0 {
+		return models.Debt{}, fmt.Errorf("principal must be greater than zero")
+	}
+	if input.AnnualRate < 0 || input.AnnualRate > 1000 {
+		return models.Debt{}, fmt.Errorf("annual rate must be between 0 and 1000 percent")
+	}
+	if input.TermMonths != nil && (*input.TermMonths <= 0 || *input.TermMonths > maxDebtTermMonths) {
+		return models.Debt{}, fmt.Errorf("term must be between 1 and %d months", maxDebtTermMonths)
+	}
+	if input.StartDate.IsZero() {
+		now := time.Now()
+		input.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Debt{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	if input.CounterpartyID, input.Counterparty, err = resolveCounterparty(db, userID, input.CounterpartyID, input.Counterparty); err != nil {
+		return models.Debt{}, err
+	}
+	if input.AccountID, err = resolveAccount(db, userID, input.AccountID); err != nil {
+		return models.Debt{}, err
+	}
+	if err := db.QueryRow(`SELECT currency FROM accounts WHERE id = $1`, input.AccountID).Scan(&input.Currency); err != nil {
+		return models.Debt{}, fmt.Errorf("failed to fetch account currency: %w", err)
+	}
+	if err := validateAmount(input.Principal, input.Currency); err != nil {
+		return models.Debt{}, err
+	}
+
+	var debtID int
+	err = db.QueryRow(`
+		INSERT INTO debts (user_id, counterparty_id, direction, principal, currency, annual_rate, term_months, start_date, account_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`, userID, input.CounterpartyID, input.Direction, input.Principal, input.Currency, input.AnnualRate, input.TermMonths,
+		input.StartDate, input.AccountID, input.Note, time.Now()).Scan(&debtID)
+	if err != nil {
+		return models.Debt{}, fmt.Errorf("failed to create debt: %w", err)
+	}
+
+	if recordDisbursement {
+		if input.Direction == DebtDirectionLent {
+			category, err := resolveCategory(db, userID, CategoryKindExpense, DefaultLoanGivenCategory)
+			if err != nil {
+				return models.Debt{}, err
+			}
+			if _, err := createTransaction(db, userID, input.AccountID, input.Principal, category, "Loan to "+input.Counterparty, time.Now()); err != nil {
+				return models.Debt{}, err
+			}
+		} else {
+			source, err := resolveCategory(db, userID, CategoryKindIncome, DefaultLoanReceivedSource)
+			if err != nil {
+				return models.Debt{}, err
+			}
+			if _, err := createIncome(db, userID, input.AccountID, input.Principal, source, time.Now()); err != nil {
+				return models.Debt{}, err
+			}
+		}
+	}
+
+	debt, err := scanDebt(db.QueryRow(`SELECT `+debtColumns+` WHERE d.id = $1`, debtID))
+	if err != nil {
+		return models.Debt{}, fmt.Errorf("failed to fetch debt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Debt{}, fmt.Errorf("failed to commit debt: %w", err)
+	}
+
+	if err := fillDebtBalance(withContext(ctx, config.Database), &debt); err != nil {
+		return models.Debt{}, err
+	}
+	return debt, nil
+}
+
+func GetDebts(userID int) ([]models.Debt, error) {
+	rows, err := config.Database.Query(`SELECT `+debtColumns+` WHERE d.user_id = $1 ORDER BY d.closed_at IS NOT NULL, d.start_date, d.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch debts: %w", err)
+	}
+	defer rows.Close()
+
+	debts := []models.Debt{}
+	for rows.Next() {
+		debt, err := scanDebt(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan debt: %w", err)
+		}
+		debts = append(debts, debt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch debts: %w", err)
+	}
+	rows.Close()
+
+	for i := range debts {
+		if err := fillDebtBalance(config.Database, &debts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return debts, nil
+}
+
+func getDebt(db dbExecutor, debtID, userID int, lock bool) (models.Debt, error) {
+	query := `SELECT ` + debtColumns + ` WHERE d.id = $1 AND d.user_id = $2`
+	if lock {
+		query += ` FOR UPDATE OF d`
+	}
+	debt, err := scanDebt(db.QueryRow(query, debtID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Debt{}, fmt.Errorf("debt not found or does not belong to the user")
+		}
+		return models.Debt{}, fmt.Errorf("failed to fetch debt: %w", err)
+	}
+	return debt, nil
+}
+
+// GetDebt returns a debt with its outstanding balance, repayments and, for a
+// debt with a term, its amortization schedule.
+func GetDebt(debtID, userID int) (*models.Debt, error) {
+	debt, err := getDebt(config.Database, debtID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := fillDebtBalance(config.Database, &debt); err != nil {
+		return nil, err
+	}
+
+	rows, err := config.Database.Query(`
+		SELECT id, debt_id, amount, principal_part, interest_part, reference_type, reference_id, paid_at, created_at
+		FROM debt_payments WHERE debt_id = $1 ORDER BY paid_at, id
+	`, debtID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch debt payments: %w", err)
+	}
+	defer rows.Close()
+
+	debt.Payments = []models.DebtPayment{}
+	for rows.Next() {
+		var payment models.DebtPayment
+		if err := rows.Scan(&payment.ID, &payment.DebtID, &payment.Amount, &payment.PrincipalPart, &payment.InterestPart,
+			&payment.ReferenceType, &payment.ReferenceID, &payment.PaidAt, &payment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan debt payment: %w", err)
+		}
+		debt.Payments = append(debt.Payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch debt payments: %w", err)
+	}
+
+	if debt.TermMonths != nil {
+		debt.Schedule = AmortizationSchedule(debt.Principal, debt.AnnualRate, *debt.TermMonths, debt.StartDate, debt.Currency)
+	}
+
+	return &debt, nil
+}
+
+// GetDebtSchedule returns the amortization schedule of a debt with a term.
+func GetDebtSchedule(debtID, userID int) ([]models.AmortizationEntry, error) {
+	debt, err := getDebt(config.Database, debtID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if debt.TermMonths == nil {
+		return nil, fmt.Errorf("debt has no term, so there is no repayment schedule")
+	}
+	return AmortizationSchedule(debt.Principal, debt.AnnualRate, *debt.TermMonths, debt.StartDate, debt.Currency), nil
+}
+
+// fillDebtBalance computes what has been repaid and what is outstanding, and
+// for a scheduled debt the next instalment not yet covered by repayments.
+func fillDebtBalance(db dbExecutor, debt *models.Debt) error {
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(principal_part), 0), COALESCE(SUM(interest_part), 0) FROM debt_payments WHERE debt_id = $1
+	`, debt.ID).Scan(&debt.PaidPrincipal, &debt.PaidInterest)
+	if err != nil {
+		return fmt.Errorf("failed to fetch debt payments: %w", err)
+	}
+	debt.Outstanding = debt.Principal - debt.PaidPrincipal
+
+	debt.NextDueDate, debt.NextPayment = nil, nil
+	if debt.ClosedAt != nil || debt.TermMonths == nil {
+		return nil
+	}
+	var scheduled models.Money
+	for _, entry := range AmortizationSchedule(debt.Principal, debt.AnnualRate, *debt.TermMonths, debt.StartDate, debt.Currency) {
+		scheduled += entry.Principal
+		if scheduled > debt.PaidPrincipal {
+			dueDate, payment := entry.DueDate, entry.Payment
+			debt.NextDueDate, debt.NextPayment = &dueDate, &payment
+			break
+		}
+	}
+	return nil
+}
+
+// accruedDebtInterest is the simple interest on the outstanding balance since
+// the last repayment, or since the start of the debt.
+func accruedDebtInterest(db dbExecutor, debt models.Debt, now time.Time) (models.Money, error) {
+	if debt.AnnualRate == 0 || debt.Outstanding <= 0 {
+		return 0, nil
+	}
+
+	since := debt.StartDate
+	var lastPaid sql.NullTime
+	if err := db.QueryRow(`SELECT MAX(paid_at) FROM debt_payments WHERE debt_id = $1`, debt.ID).Scan(&lastPaid); err != nil {
+		return 0, fmt.Errorf("failed to fetch debt payments: %w", err)
+	}
+	if lastPaid.Valid && lastPaid.Time.After(since) {
+		since = lastPaid.Time
+	}
+
+	days := now.Sub(since).Hours() / 24
+	if days <= 0 {
+		return 0, nil
+	}
+	return roundToCurrency(debt.Outstanding.MulRate(debt.AnnualRate/100*days/365), debt.Currency), nil
+}
+
+// RecordDebtRepayment books a repayment of a debt: an income when money lent
+// comes back, an expense when paying off money borrowed. The amount first
+// covers the interest accrued since the last repayment and the rest reduces
+// the principal; the debt is closed once nothing is outstanding. accountID
+// defaults to the debt's account.
+func RecordDebtRepayment(ctx context.Context, debtID, userID int, amount models.Money, accountID int, category string) (models.DebtPayment, error) {
+	if amount <= 0 {
+		return models.DebtPayment{}, fmt.Errorf("amount must be greater than zero")
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.DebtPayment{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	debt, err := getDebt(db, debtID, userID, true)
+	if err != nil {
+		return models.DebtPayment{}, err
+	}
+	if debt.ClosedAt != nil {
+		return models.DebtPayment{}, fmt.Errorf("debt is already repaid")
+	}
+	if err := fillDebtBalance(db, &debt); err != nil {
+		return models.DebtPayment{}, err
+	}
+
+	now := time.Now()
+	accrued, err := accruedDebtInterest(db, debt, now)
+	if err != nil {
+		return models.DebtPayment{}, err
+	}
+	interest := accrued
+	if amount < interest {
+		interest = amount
+	}
+	principalPart := amount - interest
+	if principalPart > debt.Outstanding {
+		return models.DebtPayment{}, fmt.Errorf("amount exceeds the outstanding %s plus accrued interest of %s", debt.Outstanding, accrued)
+	}
+
+	if accountID == 0 {
+		accountID = debt.AccountID
+	}
+	// The repayment is booked without conversion, so it has to land on an
+	// account held in the debt's currency.
+	if accountID, err = resolveAccount(db, userID, accountID); err != nil {
+		return models.DebtPayment{}, err
+	}
+	var accountCurrency string
+	if err := db.QueryRow(`SELECT currency FROM accounts WHERE id = $1`, accountID).Scan(&accountCurrency); err != nil {
+		return models.DebtPayment{}, fmt.Errorf("failed to fetch account currency: %w", err)
+	}
+	if !strings.EqualFold(accountCurrency, debt.Currency) {
+		return models.DebtPayment{}, fmt.Errorf("account %d is held in %s but the debt is in %s", accountID, accountCurrency, debt.Currency)
+	}
+	if strings.TrimSpace(category) == "" {
+		category = DefaultDebtRepaymentCategory
+	}
+
+	payment := models.DebtPayment{DebtID: debtID, Amount: amount, PrincipalPart: principalPart, InterestPart: interest, PaidAt: now}
+	if debt.Direction == DebtDirectionLent {
+		source, err := resolveCategory(db, userID, CategoryKindIncome, category)
+		if err != nil {
+			return models.DebtPayment{}, err
+		}
+		income, err := createIncome(db, userID, accountID, amount, source, now)
+		if err != nil {
+			return models.DebtPayment{}, err
+		}
+		payment.ReferenceType, payment.ReferenceID = "incomes", income.ID
+	} else {
+		expenseCategory, err := resolveCategory(db, userID, CategoryKindExpense, category)
+		if err != nil {
+			return models.DebtPayment{}, err
+		}
+		description := fmt.Sprintf("Repayment to %s (principal %s, interest %s)", debt.Counterparty, principalPart, interest)
+		transaction, err := createTransaction(db, userID, accountID, amount, expenseCategory, description, now)
+		if err != nil {
+			return models.DebtPayment{}, err
+		}
+		payment.ReferenceType, payment.ReferenceID = "transactions", transaction.ID
+	}
+
+	err = db.QueryRow(`
+		INSERT INTO debt_payments (debt_id, amount, principal_part, interest_part, reference_type, reference_id, paid_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, debtID, amount, principalPart, interest, payment.ReferenceType, payment.ReferenceID, now, now).Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		return models.DebtPayment{}, fmt.Errorf("failed to record debt payment: %w", err)
+	}
+
+	if principalPart == debt.Outstanding {
+		if _, err := db.Exec(`UPDATE debts SET closed_at = $1 WHERE id = $2`, now, debtID); err != nil {
+			return models.DebtPayment{}, fmt.Errorf("failed to close debt: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.DebtPayment{}, fmt.Errorf("failed to commit debt payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// GetDebtSummary totals the open debts of the user, converted into the
+// reporting currency (the default account's currency unless given).
+func GetDebtSummary(userID int, currency string) (models.DebtSummary, error) {
+	currency, err := reportCurrency(config.Database, userID, currency)
+	if err != nil {
+		return models.DebtSummary{}, err
+	}
+
+	debts, err := GetDebts(userID)
+	if err != nil {
+		return models.DebtSummary{}, err
+	}
+
+	summary := models.DebtSummary{Currency: currency, Debts: []models.Debt{}}
+	converter := newCurrencyConverter(config.Database, currency)
+	now := time.Now()
+	for _, debt := range debts {
+		if debt.ClosedAt != nil {
+			continue
+		}
+		outstanding, err := converter.convert(debt.Outstanding, debt.Currency, now)
+		if err != nil {
+			return models.DebtSummary{}, err
+		}
+		if debt.Direction == DebtDirectionLent {
+			summary.Receivable += outstanding
+		} else {
+			summary.Payable += outstanding
+		}
+		summary.OpenDebts++
+		summary.Debts = append(summary.Debts, debt)
+	}
+	summary.Receivable = roundToCurrency(summary.Receivable, currency)
+	summary.Payable = roundToCurrency(summary.Payable, currency)
+	summary.Net = summary.Receivable - summary.Payable
+
+	return summary, nil
+}
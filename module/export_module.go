@@ -0,0 +1,95 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+const (
+	ExportDatasetTransactions      = "transactions"
+	ExportDatasetIncomes           = "incomes"
+	ExportDatasetItems             = "items"
+	ExportDatasetStockTransactions = "stock-transactions"
+)
+
+// exportFlushEvery is how many rows are written between flushes, so that the
+// client starts receiving data while the query is still running.
+const exportFlushEvery = 500
+
+func IsExportDataset(dataset string) bool {
+	switch dataset {
+	case ExportDatasetTransactions, ExportDatasetIncomes, ExportDatasetItems, ExportDatasetStockTransactions:
+		return true
+	}
+	return false
+}
+
+// ExportDataset streams one of the user's datasets into table, reading the
+// rows through the same functions and filters as the list endpoints, without
+// paging. Stock transactions are always exported in full.
+func ExportDataset(ctx context.Context, userID int, dataset string, query models.ListQuery, table utils.TableWriter) error {
+	query.Limit, query.Offset, query.Cursor = 0, 0, ""
+
+	rows := 0
+	flush := func() error {
+		rows++
+		if rows%exportFlushEvery == 0 {
+			return table.Flush()
+		}
+		return nil
+	}
+
+	var err error
+	switch dataset {
+	case ExportDatasetTransactions:
+		if err := table.WriteRow("id", "date", "account_id", "category", "description", "amount", "currency"); err != nil {
+			return err
+		}
+		err = StreamTransactions(ctx, userID, query, func(t models.Transaction) error {
+			if err := table.WriteRow(t.ID, t.CreatedAt, t.AccountID, t.Category, t.Description, t.Amount, t.Currency); err != nil {
+				return err
+			}
+			return flush()
+		})
+	case ExportDatasetIncomes:
+		if err := table.WriteRow("id", "date", "account_id", "source", "amount", "currency"); err != nil {
+			return err
+		}
+		err = StreamIncomes(ctx, userID, query, func(i models.Income) error {
+			if err := table.WriteRow(i.ID, i.CreatedAt, i.AccountID, i.Source, i.Amount, i.Currency); err != nil {
+				return err
+			}
+			return flush()
+		})
+	case ExportDatasetItems:
+		if err := table.WriteRow("id", "name", "description", "stock", "created_at"); err != nil {
+			return err
+		}
+		err = StreamItems(ctx, userID, query, func(i models.Item) error {
+			if err := table.WriteRow(i.ID, i.Name, i.Description, i.Stock, i.CreatedAt); err != nil {
+				return err
+			}
+			return flush()
+		})
+	case ExportDatasetStockTransactions:
+		if err := table.WriteRow("id", "date", "item_id", "item_name", "type", "quantity"); err != nil {
+			return err
+		}
+		err = StreamStockTransactions(ctx, userID, func(s models.StockTransaction) error {
+			if err := table.WriteRow(s.ID, s.CreatedAt, s.ItemID, s.ItemName, s.Type, s.Quantity); err != nil {
+				return err
+			}
+			return flush()
+		})
+	default:
+		return fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	if err != nil {
+		return err
+	}
+
+	return table.Close()
+}
@@ -0,0 +1,419 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+// goalHistoryMonths is how many full months of net income the projections
+// average over.
+const goalHistoryMonths = 6
+
+// averageDaysPerMonth converts a number of months into days for projections.
+const averageDaysPerMonth = 30.44
+
+const goalColumns = `id, user_id, name, target_amount, currency, deadline, account_id, created_at`
+
+func scanGoal(row interface{ Scan(...interface{}) error }) (models.Goal, error) {
+	var goal models.Goal
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.Name, &goal.TargetAmount, &goal.Currency, &goal.Deadline, &goal.AccountID, &goal.CreatedAt)
+	return goal, err
+}
+
+func validateGoalDeadline(deadline *time.Time) error {
+	if deadline != nil && !deadline.After(time.Now()) {
+		return fmt.Errorf("deadline must be in the future")
+	}
+	return nil
+}
+
+// CreateGoal creates a savings goal. A goal linked to an account takes the
+// account's currency and measures progress by its balance; an unlinked goal
+// uses the given currency (the reporting currency by default) and counts the
+// contributions allocated to it.
+func CreateGoal(ctx context.Context, userID int, name string, target models.Money, currency string, deadline *time.Time, accountID *int) (models.Goal, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Goal{}, fmt.Errorf("goal name cannot be empty")
+	}
+	if target <= 0 {
+		return models.Goal{}, fmt.Errorf("target amount must be greater than zero")
+	}
+	if err := validateGoalDeadline(deadline); err != nil {
+		return models.Goal{}, err
+	}
+
+	db := withContext(ctx, config.Database)
+	var err error
+	if accountID != nil {
+		if *accountID, err = resolveAccount(db, userID, *accountID); err != nil {
+			return models.Goal{}, err
+		}
+		var accountCurrency string
+		if err := db.QueryRow(`SELECT currency FROM accounts WHERE id = $1`, *accountID).Scan(&accountCurrency); err != nil {
+			return models.Goal{}, fmt.Errorf("failed to fetch account currency: %w", err)
+		}
+		if currency != "" && !strings.EqualFold(currency, accountCurrency) {
+			return models.Goal{}, fmt.Errorf("a goal linked to a %s account must be in %s", accountCurrency, accountCurrency)
+		}
+		currency = accountCurrency
+	} else if currency, err = reportCurrency(db, userID, currency); err != nil {
+		return models.Goal{}, err
+	}
+	if err := validateAmount(target, currency); err != nil {
+		return models.Goal{}, err
+	}
+
+	query := `
+		INSERT INTO goals (user_id, name, target_amount, currency, deadline, account_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + goalColumns
+	goal, err := scanGoal(db.QueryRow(query, userID, name, target, currency, deadline, accountID, time.Now()))
+	if err != nil {
+		return models.Goal{}, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	if err := fillGoalProgress(ctx, &goal, map[string]models.Money{}); err != nil {
+		return models.Goal{}, err
+	}
+	return goal, nil
+}
+
+func GetGoals(ctx context.Context, userID int) ([]models.Goal, error) {
+	rows, err := config.Database.QueryContext(ctx, `SELECT `+goalColumns+` FROM goals WHERE user_id = $1 ORDER BY deadline NULLS LAST, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %w", err)
+	}
+	rows.Close()
+
+	averages := map[string]models.Money{}
+	for i := range goals {
+		if err := fillGoalProgress(ctx, &goals[i], averages); err != nil {
+			return nil, err
+		}
+	}
+
+	return goals, nil
+}
+
+func getGoal(db dbExecutor, goalID, userID int, lock bool) (models.Goal, error) {
+	query := `SELECT ` + goalColumns + ` FROM goals WHERE id = $1 AND user_id = $2`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+	goal, err := scanGoal(db.QueryRow(query, goalID, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Goal{}, fmt.Errorf("goal not found or does not belong to the user")
+		}
+		return models.Goal{}, fmt.Errorf("failed to fetch goal: %w", err)
+	}
+	return goal, nil
+}
+
+// GetGoal returns a goal with its progress and contributions.
+func GetGoal(ctx context.Context, goalID, userID int) (*models.Goal, error) {
+	goal, err := getGoal(withContext(ctx, config.Database), goalID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := fillGoalProgress(ctx, &goal, map[string]models.Money{}); err != nil {
+		return nil, err
+	}
+
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT id, goal_id, amount, note, transfer_id, created_at FROM goal_contributions
+		WHERE goal_id = $1 ORDER BY created_at DESC, id DESC
+	`, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goal contributions: %w", err)
+	}
+	defer rows.Close()
+
+	goal.Contributions = []models.GoalContribution{}
+	for rows.Next() {
+		var contribution models.GoalContribution
+		if err := rows.Scan(&contribution.ID, &contribution.GoalID, &contribution.Amount, &contribution.Note, &contribution.TransferID, &contribution.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal contribution: %w", err)
+		}
+		goal.Contributions = append(goal.Contributions, contribution)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch goal contributions: %w", err)
+	}
+
+	return &goal, nil
+}
+
+// UpdateGoal changes the name, target and deadline of a goal. The linked
+// account and the currency are fixed once the goal exists.
+func UpdateGoal(ctx context.Context, goalID, userID int, name string, target models.Money, deadline *time.Time) (*models.Goal, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("goal name cannot be empty")
+	}
+	if target <= 0 {
+		return nil, fmt.Errorf("target amount must be greater than zero")
+	}
+	if err := validateGoalDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	goal, err := getGoal(withContext(ctx, config.Database), goalID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAmount(target, goal.Currency); err != nil {
+		return nil, err
+	}
+
+	goal, err = scanGoal(config.Database.QueryRowContext(ctx, `
+		UPDATE goals SET name = $1, target_amount = $2, deadline = $3 WHERE id = $4 AND user_id = $5
+		RETURNING `+goalColumns, name, target, deadline, goalID, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	if err := fillGoalProgress(ctx, &goal, map[string]models.Money{}); err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// DeleteGoal removes a goal and its contributions. Money moved into a linked
+// account by contributions stays there.
+func DeleteGoal(ctx context.Context, goalID, userID int) error {
+	result, err := config.Database.ExecContext(ctx, `DELETE FROM goals WHERE id = $1 AND user_id = $2`, goalID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("goal not found or does not belong to the user")
+	}
+
+	return nil
+}
+
+// AddGoalContribution puts money towards a goal. For a goal linked to an
+// account the contribution is a transfer from fromAccountID (the default
+// account when zero) into the linked account, recorded at the amount that
+// arrived. For an unlinked goal it only allocates money; a negative amount
+// releases an earlier allocation.
+func AddGoalContribution(ctx context.Context, goalID, userID int, amount models.Money, fromAccountID int, note string) (models.GoalContribution, error) {
+	if amount == 0 {
+		return models.GoalContribution{}, fmt.Errorf("amount must not be zero")
+	}
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.GoalContribution{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	goal, err := getGoal(db, goalID, userID, true)
+	if err != nil {
+		return models.GoalContribution{}, err
+	}
+	if note = strings.TrimSpace(note); note == "" {
+		note = "Contribution to " + goal.Name
+	}
+
+	var transferID *int
+	if goal.AccountID != nil {
+		if amount < 0 {
+			return models.GoalContribution{}, fmt.Errorf("contributions to a goal linked to an account must be positive; transfer money out of the account instead")
+		}
+		transfer, err := createTransfer(db, userID, fromAccountID, *goal.AccountID, amount, note)
+		if err != nil {
+			return models.GoalContribution{}, err
+		}
+		amount = transfer.ToAmount
+		transferID = &transfer.ID
+	} else {
+		if err := validateAmount(amount, goal.Currency); err != nil {
+			return models.GoalContribution{}, err
+		}
+		var allocated models.Money
+		if err := db.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM goal_contributions WHERE goal_id = $1`, goalID).Scan(&allocated); err != nil {
+			return models.GoalContribution{}, fmt.Errorf("failed to fetch goal contributions: %w", err)
+		}
+		if allocated+amount < 0 {
+			return models.GoalContribution{}, fmt.Errorf("cannot release %s, only %s is allocated to the goal", -amount, allocated)
+		}
+	}
+
+	var contribution models.GoalContribution
+	err = db.QueryRow(`
+		INSERT INTO goal_contributions (goal_id, amount, note, transfer_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, goal_id, amount, note, transfer_id, created_at
+	`, goalID, amount, note, transferID, time.Now()).Scan(
+		&contribution.ID, &contribution.GoalID, &contribution.Amount, &contribution.Note, &contribution.TransferID, &contribution.CreatedAt,
+	)
+	if err != nil {
+		return models.GoalContribution{}, fmt.Errorf("failed to record goal contribution: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.GoalContribution{}, fmt.Errorf("failed to commit goal contribution: %w", err)
+	}
+
+	return contribution, nil
+}
+
+// averageMonthlySavings is the user's mean net income (incomes minus
+// expenses) over the last full months, in currency. averages caches the
+// result per currency while several goals are filled in.
+func averageMonthlySavings(ctx context.Context, userID int, currency string, averages map[string]models.Money) (models.Money, error) {
+	if average, ok := averages[currency]; ok {
+		return average, nil
+	}
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := thisMonth.AddDate(0, -goalHistoryMonths, 0)
+	to := thisMonth.AddDate(0, 0, -1)
+
+	report, err := GetCashFlowReport(ctx, userID, ReportGranularityMonth, from.Format("2006-01-02"), to.Format("2006-01-02"), "UTC", currency)
+	if err != nil {
+		return 0, err
+	}
+
+	average := models.Money(0)
+	if len(report.Periods) > 0 {
+		average = roundToCurrency(report.Net/models.Money(len(report.Periods)), currency)
+	}
+	averages[currency] = average
+	return average, nil
+}
+
+// fillGoalProgress computes the saved amount, progress and projected
+// completion date of a goal.
+func fillGoalProgress(ctx context.Context, goal *models.Goal, averages map[string]models.Money) error {
+	if goal.AccountID != nil {
+		if err := config.Database.QueryRowContext(ctx, `SELECT balance FROM accounts WHERE id = $1`, *goal.AccountID).Scan(&goal.Saved); err != nil {
+			return fmt.Errorf("failed to fetch goal account balance: %w", err)
+		}
+	} else {
+		if err := config.Database.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0) FROM goal_contributions WHERE goal_id = $1`, goal.ID).Scan(&goal.Saved); err != nil {
+			return fmt.Errorf("failed to fetch goal contributions: %w", err)
+		}
+	}
+
+	goal.Remaining = goal.TargetAmount - goal.Saved
+	if goal.Remaining < 0 {
+		goal.Remaining = 0
+	}
+	goal.Progress = math.Round(goal.Saved.Float64()/goal.TargetAmount.Float64()*10000) / 100
+	goal.Achieved = goal.Saved >= goal.TargetAmount
+
+	average, err := averageMonthlySavings(ctx, goal.UserID, goal.Currency, averages)
+	if err != nil {
+		return err
+	}
+	goal.AverageMonthlySavings = average
+
+	goal.ProjectedCompletion = nil
+	goal.OnTrack = nil
+	if goal.Achieved || average <= 0 {
+		return nil
+	}
+	months := goal.Remaining.Float64() / average.Float64()
+	now := time.Now().UTC()
+	projected := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(math.Ceil(months*averageDaysPerMonth)))
+	goal.ProjectedCompletion = &projected
+	if goal.Deadline != nil {
+		onTrack := !projected.After(*goal.Deadline)
+		goal.OnTrack = &onTrack
+	}
+
+	return nil
+}
+
+// ceilToCurrency rounds a positive amount up to the minor unit of currency,
+// so that a suggested contribution never falls short of the target.
+func ceilToCurrency(amount models.Money, currency string) models.Money {
+	exponent, ok := utils.CurrencyExponent(currency)
+	if !ok || exponent >= models.MoneyScale {
+		return amount
+	}
+	unit := models.Money(math.Pow10(models.MoneyScale - exponent))
+	return (amount + unit - 1) / unit * unit
+}
+
+// SuggestGoalContribution works out the monthly contribution needed to reach
+// a goal by its deadline and compares it with what the user has been saving.
+func SuggestGoalContribution(ctx context.Context, goalID, userID int) (models.GoalSuggestion, error) {
+	goal, err := getGoal(withContext(ctx, config.Database), goalID, userID, false)
+	if err != nil {
+		return models.GoalSuggestion{}, err
+	}
+	if goal.Deadline == nil {
+		return models.GoalSuggestion{}, fmt.Errorf("goal has no deadline")
+	}
+	if err := fillGoalProgress(ctx, &goal, map[string]models.Money{}); err != nil {
+		return models.GoalSuggestion{}, err
+	}
+
+	suggestion := models.GoalSuggestion{
+		GoalID:                goal.ID,
+		Currency:              goal.Currency,
+		Remaining:             goal.Remaining,
+		Deadline:              goal.Deadline,
+		AverageMonthlySavings: goal.AverageMonthlySavings,
+	}
+
+	if goal.Achieved {
+		suggestion.Feasible = true
+		suggestion.Message = "The goal has been reached."
+		return suggestion, nil
+	}
+
+	days := goal.Deadline.Sub(time.Now()).Hours() / 24
+	if days <= 0 {
+		suggestion.MonthlyContribution = goal.Remaining
+		suggestion.Message = "The deadline has passed; the remaining amount is due now."
+		return suggestion, nil
+	}
+
+	suggestion.MonthsLeft = int(math.Ceil(days / averageDaysPerMonth))
+	months := models.Money(suggestion.MonthsLeft)
+	suggestion.MonthlyContribution = ceilToCurrency((goal.Remaining+months-1)/months, goal.Currency)
+	suggestion.Feasible = goal.AverageMonthlySavings >= suggestion.MonthlyContribution
+	if suggestion.Feasible {
+		suggestion.Message = fmt.Sprintf("Setting aside %s %s a month reaches the goal by the deadline.", suggestion.MonthlyContribution, goal.Currency)
+	} else {
+		suggestion.Message = fmt.Sprintf("Reaching the goal by the deadline takes %s %s a month, more than the %s %s saved on average.",
+			suggestion.MonthlyContribution, goal.Currency, goal.AverageMonthlySavings, goal.Currency)
+	}
+
+	return suggestion, nil
+}
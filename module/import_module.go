@@ -0,0 +1,557 @@
+package module
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+const (
+	ImportFormatCSV = "csv"
+	ImportFormatOFX = "ofx"
+	ImportFormatQIF = "qif"
+)
+
+const (
+	ImportStatusAccepted  = "accepted"
+	ImportStatusDuplicate = "duplicate"
+	ImportStatusInvalid   = "invalid"
+)
+
+// importDateLayouts are tried in order when the mapping has no date format.
+var importDateLayouts = []string{"2006-01-02", "1/2/2006", "2.1.2006", "2006/01/02", "20060102", "1/2/06"}
+
+func IsImportFormat(format string) bool {
+	switch format {
+	case ImportFormatCSV, ImportFormatOFX, ImportFormatQIF:
+		return true
+	}
+	return false
+}
+
+// ParseImportFile reads a CSV, OFX or QIF file into import rows. Problems with
+// a single row are recorded on the row; an error is only returned when the
+// file or the mapping cannot be used at all.
+func ParseImportFile(format string, r io.Reader, mapping models.ImportMapping) ([]models.ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseImportCSV(r, mapping)
+	case ImportFormatOFX:
+		return parseImportOFX(r)
+	case ImportFormatQIF:
+		return parseImportQIF(r, mapping.DateFormat)
+	}
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+func invalidRow(row *models.ImportRow, format string, args ...interface{}) {
+	row.Status = ImportStatusInvalid
+	row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+}
+
+// parseImportAmount accepts amounts as banks write them: with currency symbols,
+// thousands separators and accounting-style parentheses for negatives.
+func parseImportAmount(s string, decimalComma bool) (models.Money, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = text[1 : len(text)-1]
+	}
+
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			b.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		}
+	}
+	text = b.String()
+	if decimalComma {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, ".", ""), ",", ".")
+	} else {
+		text = strings.ReplaceAll(text, ",", "")
+	}
+
+	amount, err := models.ParseMoney(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func parseImportDate(s, layout string) (time.Time, error) {
+	text := strings.TrimSpace(s)
+	layouts := importDateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if date, err := time.Parse(l, text); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// setSignedAmount fills in the kind and amount of a row from a signed amount.
+func setSignedAmount(row *models.ImportRow, amount models.Money) {
+	switch {
+	case amount > 0:
+		row.Kind = CategoryKindIncome
+		row.Amount = amount
+	case amount < 0:
+		row.Kind = CategoryKindExpense
+		row.Amount = -amount
+	default:
+		invalidRow(row, "amount must not be zero")
+	}
+}
+
+func parseImportCSV(r io.Reader, mapping models.ImportMapping) ([]models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	switch mapping.Delimiter {
+	case "":
+	case "tab", "\t":
+		reader.Comma = '\t'
+	default:
+		delimiter, size := utf8.DecodeRuneInString(mapping.Delimiter)
+		if size != len(mapping.Delimiter) {
+			return nil, fmt.Errorf("delimiter must be a single character")
+		}
+		reader.Comma = delimiter
+	}
+
+	if mapping.Date == "" {
+		return nil, fmt.Errorf("mapping must name the date column")
+	}
+	if mapping.Amount == "" && mapping.Debit == "" && mapping.Credit == "" {
+		return nil, fmt.Errorf("mapping must name an amount column or debit and credit columns")
+	}
+
+	header := map[string]int{}
+	line := 0
+	if !mapping.NoHeader {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		line++
+		for i, name := range record {
+			header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+		}
+	}
+
+	column := func(field, ref string) (int, error) {
+		if ref == "" {
+			return -1, nil
+		}
+		if i, ok := header[strings.ToLower(strings.TrimSpace(ref))]; ok {
+			return i, nil
+		}
+		if i, err := strconv.Atoi(ref); err == nil && i > 0 {
+			return i - 1, nil
+		}
+		return 0, fmt.Errorf("%s column %q not found", field, ref)
+	}
+	columns := map[string]int{}
+	for field, ref := range map[string]string{
+		"date": mapping.Date, "amount": mapping.Amount, "debit": mapping.Debit, "credit": mapping.Credit,
+		"type": mapping.Type, "description": mapping.Description, "category": mapping.Category,
+	} {
+		i, err := column(field, ref)
+		if err != nil {
+			return nil, err
+		}
+		columns[field] = i
+	}
+
+	var rows []models.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		value := func(field string) string {
+			i := columns[field]
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := models.ImportRow{Line: line, Status: ImportStatusAccepted, Description: value("description"), Category: value("category")}
+		if row.Date, err = parseImportDate(value("date"), mapping.DateFormat); err != nil {
+			invalidRow(&row, "%v", err)
+		}
+
+		switch {
+		case mapping.Amount != "":
+			amount, err := parseImportAmount(value("amount"), mapping.DecimalComma)
+			if err != nil {
+				invalidRow(&row, "%v", err)
+				break
+			}
+			if mapping.Type == "" {
+				setSignedAmount(&row, amount)
+				break
+			}
+			if amount < 0 {
+				amount = -amount
+			}
+			switch strings.ToLower(value("type")) {
+			case "income", "credit", "cr", "in", "deposit":
+				setSignedAmount(&row, amount)
+			case "expense", "debit", "dr", "out", "withdrawal":
+				setSignedAmount(&row, -amount)
+			default:
+				invalidRow(&row, "unknown type %q", value("type"))
+			}
+		default:
+			debit, credit := value("debit"), value("credit")
+			if (debit == "") == (credit == "") {
+				invalidRow(&row, "exactly one of debit and credit must be filled in")
+				break
+			}
+			text, sign := credit, models.Money(1)
+			if debit != "" {
+				text, sign = debit, -1
+			}
+			amount, err := parseImportAmount(text, mapping.DecimalComma)
+			if err != nil {
+				invalidRow(&row, "%v", err)
+				break
+			}
+			if amount < 0 {
+				amount = -amount
+			}
+			setSignedAmount(&row, sign*amount)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+var (
+	ofxTransactionPattern = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxFieldPattern       = regexp.MustCompile(`(?i)<([A-Z0-9.]+)>([^<\r\n]*)`)
+)
+
+// parseImportOFX reads the statement transactions of an OFX file. Both the
+// SGML flavour of OFX 1.x, where closing tags are optional, and the XML of OFX
+// 2.x are accepted. Rows are numbered by their position in the statement.
+func parseImportOFX(r io.Reader) ([]models.ImportRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OFX file: %w", err)
+	}
+	if !strings.Contains(strings.ToUpper(string(data)), "<OFX>") {
+		return nil, fmt.Errorf("file is not an OFX statement")
+	}
+
+	var rows []models.ImportRow
+	for i, match := range ofxTransactionPattern.FindAllStringSubmatch(string(data), -1) {
+		fields := map[string]string{}
+		for _, field := range ofxFieldPattern.FindAllStringSubmatch(match[1], -1) {
+			fields[strings.ToUpper(field[1])] = strings.TrimSpace(field[2])
+		}
+
+		row := models.ImportRow{Line: i + 1, Status: ImportStatusAccepted, Description: fields["NAME"]}
+		if memo := fields["MEMO"]; memo != "" {
+			if row.Description == "" {
+				row.Description = memo
+			} else if !strings.EqualFold(memo, row.Description) {
+				row.Description += " - " + memo
+			}
+		}
+
+		// DTPOSTED is YYYYMMDD optionally followed by the time and timezone.
+		posted := fields["DTPOSTED"]
+		if len(posted) < 8 {
+			invalidRow(&row, "invalid date %q", posted)
+		} else if row.Date, err = time.Parse("20060102", posted[:8]); err != nil {
+			invalidRow(&row, "invalid date %q", posted)
+		}
+
+		amount, err := parseImportAmount(fields["TRNAMT"], false)
+		if err != nil {
+			invalidRow(&row, "%v", err)
+		} else {
+			setSignedAmount(&row, amount)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseImportQIF reads the records of a QIF bank statement. Dates default to
+// the US month/day order QIF files are usually written in; the apostrophe some
+// programs put before two-digit years is accepted.
+func parseImportQIF(r io.Reader, dateFormat string) ([]models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	var rows []models.ImportRow
+	var row *models.ImportRow
+	var date, amount string
+	line := 0
+
+	finish := func() {
+		if row == nil {
+			return
+		}
+		var err error
+		date = strings.ReplaceAll(strings.ReplaceAll(date, "' ", "/"), "'", "/")
+		date = strings.ReplaceAll(date, " ", "")
+		if row.Date, err = parseImportDate(date, dateFormat); err != nil {
+			invalidRow(row, "%v", err)
+		}
+		if value, err := parseImportAmount(amount, false); err != nil {
+			invalidRow(row, "%v", err)
+		} else {
+			setSignedAmount(row, value)
+		}
+		rows = append(rows, *row)
+		row, date, amount = nil, "", ""
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		if text[0] == '^' {
+			finish()
+			continue
+		}
+		if row == nil {
+			row = &models.ImportRow{Line: line, Status: ImportStatusAccepted}
+		}
+
+		value := strings.TrimSpace(text[1:])
+		switch text[0] {
+		case 'D':
+			date = value
+		case 'T', 'U':
+			amount = value
+		case 'P':
+			row.Description = value
+		case 'M':
+			if row.Description == "" {
+				row.Description = value
+			}
+		case 'L':
+			// Bracketed categories are transfers to other QIF accounts.
+			if !strings.HasPrefix(value, "[") {
+				row.Category = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read QIF file: %w", err)
+	}
+	finish()
+
+	return rows, nil
+}
+
+// ImportTransactions books the accepted rows on one of the user's accounts in
+// a single database transaction. Expenses without a category are categorized
+// by the user's rules, falling back to defaultCategory. Rows matching an existing income or expense
+// of the account on the same day with the same amount and text are marked as
+// duplicates and skipped unless allowDuplicates is set. Each row is booked
+// under a savepoint so that a row the ledger rejects (for instance for
+// insufficient funds) is reported without aborting the others. A dry run goes
+// through the same steps and rolls everything back.
+func ImportTransactions(ctx context.Context, userID, accountID int, format string, rows []models.ImportRow, defaultCategory string, dryRun, allowDuplicates bool) (models.ImportResult, error) {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ImportResult{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	accountID, err = resolveAccount(db, userID, accountID)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	if strings.TrimSpace(defaultCategory) == "" {
+		defaultCategory = "Uncategorized"
+	}
+	matchers, err := loadCategoryRules(db, userID)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	// Rows are booked in date order so that incomes are available to the
+	// expenses that follow them.
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rows[order[a]].Date.Before(rows[order[b]].Date)
+	})
+
+	seen := map[string]int{}
+	for _, i := range order {
+		row := &rows[i]
+		if row.Status != ImportStatusAccepted {
+			continue
+		}
+
+		kind := CategoryKindExpense
+		name := row.Category
+		if row.Kind == CategoryKindIncome {
+			kind = CategoryKindIncome
+			if name == "" {
+				name = row.Description
+			}
+		} else if name == "" {
+			if rule, ok := matchCategoryRule(matchers, accountID, row.Amount, row.Description); ok {
+				name = rule.Category
+			}
+		}
+		if name == "" {
+			name = defaultCategory
+		}
+		if row.Category, err = resolveCategory(db, userID, kind, name); err != nil {
+			invalidRow(row, "%v", err)
+			continue
+		}
+
+		duplicate, err := isDuplicateImportRow(db, userID, accountID, *row, seen)
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		if duplicate && !allowDuplicates {
+			row.Status = ImportStatusDuplicate
+			continue
+		}
+
+		if _, err := db.Exec(`SAVEPOINT import_row`); err != nil {
+			return models.ImportResult{}, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		if row.Kind == CategoryKindIncome {
+			var income models.Income
+			income, err = createIncome(db, userID, accountID, row.Amount, row.Category, row.Date)
+			row.ReferenceID = income.ID
+		} else {
+			var transaction models.Transaction
+			transaction, err = createTransaction(db, userID, accountID, row.Amount, row.Category, row.Description, row.Date)
+			row.ReferenceID = transaction.ID
+		}
+		if err != nil {
+			if _, rollbackErr := db.Exec(`ROLLBACK TO SAVEPOINT import_row`); rollbackErr != nil {
+				return models.ImportResult{}, fmt.Errorf("failed to roll back row %d: %w", row.Line, rollbackErr)
+			}
+			row.ReferenceID = 0
+			invalidRow(row, "%v", err)
+			continue
+		}
+		if _, err := db.Exec(`RELEASE SAVEPOINT import_row`); err != nil {
+			return models.ImportResult{}, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		if dryRun {
+			row.ReferenceID = 0
+		}
+	}
+
+	result := models.ImportResult{Format: format, AccountID: accountID, DryRun: dryRun, Total: len(rows), Rows: rows}
+	if result.Rows == nil {
+		result.Rows = []models.ImportRow{}
+	}
+	for _, row := range rows {
+		switch row.Status {
+		case ImportStatusAccepted:
+			result.Accepted++
+		case ImportStatusDuplicate:
+			result.Duplicates++
+		default:
+			result.Invalid++
+		}
+	}
+
+	if dryRun || result.Accepted == 0 {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ImportResult{}, fmt.Errorf("failed to commit import: %w", err)
+	}
+	result.Committed = true
+
+	for _, row := range rows {
+		if row.Status != ImportStatusAccepted {
+			continue
+		}
+		if row.Kind == CategoryKindIncome {
+			metrics.IncomesCreated(metrics.SourceImport, 1)
+		} else {
+			metrics.ExpensesCreated(metrics.SourceImport, 1)
+		}
+	}
+
+	return result, nil
+}
+
+// isDuplicateImportRow reports whether the row repeats an existing income or
+// expense. Identical rows within the file are only duplicates beyond the
+// number already stored, so that two genuine purchases of the same amount on
+// the same day can still be imported into an empty account.
+func isDuplicateImportRow(db dbExecutor, userID, accountID int, row models.ImportRow, seen map[string]int) (bool, error) {
+	day := time.Date(row.Date.Year(), row.Date.Month(), row.Date.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := `
+		SELECT COUNT(*) FROM transactions
+		WHERE user_id = $1 AND account_id = $2 AND amount = $3 AND created_at >= $4 AND created_at < $5
+		AND LOWER(TRIM(description)) = LOWER(TRIM($6))
+	`
+	text := row.Description
+	if row.Kind == CategoryKindIncome {
+		query = `
+			SELECT COUNT(*) FROM incomes
+			WHERE user_id = $1 AND account_id = $2 AND amount = $3 AND created_at >= $4 AND created_at < $5
+			AND LOWER(TRIM(source)) = LOWER(TRIM($6))
+		`
+		text = row.Category
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", row.Kind, day.Format("2006-01-02"), row.Amount, strings.ToLower(strings.TrimSpace(text)))
+	seen[key]++
+
+	var existing int
+	if err := db.QueryRow(query, userID, accountID, row.Amount, day, day.AddDate(0, 0, 1), text).Scan(&existing); err != nil {
+		return false, fmt.Errorf("failed to check for duplicates: %w", err)
+	}
+	return seen[key] <= existing, nil
+}
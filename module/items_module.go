@@ -1,26 +1,34 @@
 package module
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
 	"github.com/Sc01100100/SaveCash-API/models"
 )
 
-func RestockItem(userID, itemID, quantity int) error {
+func RestockItem(ctx context.Context, userID, itemID, quantity int) error {
     if quantity <= 0 {
         return fmt.Errorf("quantity must be greater than zero")
     }
 
     var item models.Item
-    err := config.Database.QueryRow(`SELECT id, name, stock FROM items WHERE id = $1`, itemID).Scan(&item.ID, &item.Name, &item.Stock)
+    err := config.Database.QueryRowContext(ctx, `SELECT id, name, stock FROM items WHERE id = $1 AND user_id = $2`, itemID, userID).Scan(&item.ID, &item.Name, &item.Stock)
     if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("item not found or does not belong to the user")
+        }
         return fmt.Errorf("failed to fetch item: %w", err)
     }
 
     item.Stock += quantity
-    _, err = config.Database.Exec(`UPDATE items SET stock = $1 WHERE id = $2`, item.Stock, itemID)
+    _, err = config.Database.ExecContext(ctx, `UPDATE items SET stock = $1 WHERE id = $2`, item.Stock, itemID)
     if err != nil {
         return fmt.Errorf("failed to update stock: %w", err)
     }
@@ -34,7 +42,7 @@ func RestockItem(userID, itemID, quantity int) error {
         UserID:    userID,
     }
 
-    _, err = config.Database.Exec(`
+    _, err = config.Database.ExecContext(ctx, `
         INSERT INTO stock_transactions (item_id, item_name, quantity, type, created_at, user_id)
         VALUES ($1, $2, $3, $4, $5, $6)`,
         stockTransaction.ItemID, stockTransaction.ItemName, stockTransaction.Quantity,
@@ -47,14 +55,30 @@ func RestockItem(userID, itemID, quantity int) error {
     return nil
 }
 
-func SellItem(userID, itemID, quantity int) error {
+// SellItem takes quantity units out of stock. When the sale brings in money,
+// amount is recorded as an income on the given account (zero for the default
+// account) and posted to the journal as a stock sale.
+func SellItem(ctx context.Context, userID, itemID, quantity, accountID int, amount models.Money) error {
     if quantity <= 0 {
         return fmt.Errorf("quantity must be greater than zero")
     }
+    if amount < 0 {
+        return fmt.Errorf("amount cannot be negative")
+    }
+
+    tx, err := config.Database.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to start database transaction: %w", err)
+    }
+    defer tx.Rollback()
+    db := withContext(ctx, tx)
 
     var item models.Item
-    err := config.Database.QueryRow(`SELECT id, name, stock FROM items WHERE id = $1`, itemID).Scan(&item.ID, &item.Name, &item.Stock)
+    err = db.QueryRow(`SELECT id, name, stock FROM items WHERE id = $1 AND user_id = $2 FOR UPDATE`, itemID, userID).Scan(&item.ID, &item.Name, &item.Stock)
     if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("item not found or does not belong to the user")
+        }
         return fmt.Errorf("failed to fetch item: %w", err)
     }
 
@@ -63,21 +87,21 @@ func SellItem(userID, itemID, quantity int) error {
     }
 
     item.Stock -= quantity
-    _, err = config.Database.Exec(`UPDATE items SET stock = $1 WHERE id = $2`, item.Stock, itemID)
+    _, err = db.Exec(`UPDATE items SET stock = $1 WHERE id = $2`, item.Stock, itemID)
     if err != nil {
         return fmt.Errorf("failed to update stock: %w", err)
     }
 
     stockTransaction := models.StockTransaction{
         ItemID:    itemID,
-        ItemName:  item.Name, 
+        ItemName:  item.Name,
         Quantity:  -quantity,
         Type:      "OUT",
         CreatedAt: time.Now(),
         UserID:    userID,
     }
 
-    _, err = config.Database.Exec(`
+    _, err = db.Exec(`
         INSERT INTO stock_transactions (item_id, item_name, quantity, type, created_at, user_id)
         VALUES ($1, $2, $3, $4, $5, $6)`,
         stockTransaction.ItemID, stockTransaction.ItemName, stockTransaction.Quantity,
@@ -87,5 +111,120 @@ func SellItem(userID, itemID, quantity int) error {
         return fmt.Errorf("failed to record stock transaction: %w", err)
     }
 
+    if amount > 0 {
+        source := fmt.Sprintf("Sale of %d x %s", quantity, item.Name)
+        if _, err := recordIncome(db, userID, accountID, amount, source, stockTransaction.CreatedAt, JournalKindStockSale); err != nil {
+            return fmt.Errorf("failed to record sale proceeds: %w", err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit sale: %w", err)
+    }
+    metrics.ItemsSold(quantity)
+    if amount > 0 {
+        metrics.IncomesCreated(metrics.SourceStockSale, 1)
+    }
+
+    return nil
+}
+
+// itemList is how the item list endpoint and exports filter, sort and page
+// items. Items have no amount or category.
+var itemList = listSpec[models.Item]{
+    name:    "items",
+    table:   "items",
+    columns: `id, user_id, name, description, stock, created_at`,
+    scan: func(row interface{ Scan(...interface{}) error }) (models.Item, error) {
+        var item models.Item
+        err := row.Scan(&item.ID, &item.UserID, &item.Name, &item.Description, &item.Stock, &item.CreatedAt)
+        return item, err
+    },
+    id: func(item models.Item) int { return item.ID },
+
+    userColumn:    "user_id",
+    dateColumn:    "created_at",
+    searchColumns: []string{"name", "description"},
+    tagOwner:      TagOwnerItem,
+
+    sorts: map[string]listSort[models.Item]{
+        "created_at": {"created_at", "timestamp", func(item models.Item) string { return item.CreatedAt.Format(time.RFC3339Nano) }},
+        "name":       {"name", "text", func(item models.Item) string { return item.Name }},
+        "stock":      {"stock", "integer", func(item models.Item) string { return strconv.Itoa(item.Stock) }},
+    },
+    defaultSort:  "created_at",
+    defaultOrder: ListOrderDesc,
+}
+
+// GetItems returns one page of the user's items matching the query, with
+// their tags.
+func GetItems(ctx context.Context, userID int, query models.ListQuery) ([]models.Item, models.Page, error) {
+    items, page, err := itemList.page(ctx, userID, query)
+    if err != nil {
+        return nil, models.Page{}, err
+    }
+
+    ids := make([]int, len(items))
+    for i, item := range items {
+        ids[i] = item.ID
+    }
+    entryTags, err := loadTags(withContext(ctx, config.Database), TagOwnerItem, ids)
+    if err != nil {
+        return nil, models.Page{}, err
+    }
+    for i := range items {
+        items[i].Tags = entryTags[items[i].ID]
+    }
+
+    return items, page, nil
+}
+
+// StreamItems calls fn for each of the user's items matching the query
+// without loading them all into memory. It stops at the first error fn returns.
+func StreamItems(ctx context.Context, userID int, query models.ListQuery, fn func(models.Item) error) error {
+    return itemList.stream(ctx, userID, query, fn)
+}
+
+func GetStockTransactions(ctx context.Context, userID int) ([]models.StockTransaction, error) {
+    transactions := []models.StockTransaction{}
+    err := StreamStockTransactions(ctx, userID, func(transaction models.StockTransaction) error {
+        transactions = append(transactions, transaction)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return transactions, nil
+}
+
+// StreamStockTransactions calls fn for each of the user's stock movements,
+// most recent first, without loading them all into memory.
+func StreamStockTransactions(ctx context.Context, userID int, fn func(models.StockTransaction) error) error {
+    rows, err := config.Database.QueryContext(ctx, `
+        SELECT id, item_id, item_name, quantity, type, created_at, user_id
+        FROM stock_transactions
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `, userID)
+    if err != nil {
+        return fmt.Errorf("failed to fetch stock transactions: %w", err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var transaction models.StockTransaction
+        if err := rows.Scan(&transaction.ID, &transaction.ItemID, &transaction.ItemName, &transaction.Quantity, &transaction.Type, &transaction.CreatedAt, &transaction.UserID); err != nil {
+            return fmt.Errorf("failed to scan stock transaction: %w", err)
+        }
+        if err := fn(transaction); err != nil {
+            return err
+        }
+    }
+
+    if err := rows.Err(); err != nil {
+        return fmt.Errorf("failed to fetch stock transactions: %w", err)
+    }
+
     return nil
 }
\ No newline at end of file
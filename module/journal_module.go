@@ -0,0 +1,292 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+// Journal entry kinds, one per operation that moves money.
+const (
+	JournalKindIncome     = "income"
+	JournalKindExpense    = "expense"
+	JournalKindTransfer   = "transfer"
+	JournalKindStockSale  = "stock_sale"
+	JournalKindOpening    = "opening"
+	JournalKindAdjustment = "adjustment"
+	JournalKindReversal   = "reversal"
+)
+
+// Ledgers a journal line can post to. Asset lines point at one of the user's
+// accounts; income and expense lines are named after the source or category;
+// equity holds opening balances and exchange balances currency conversions.
+const (
+	LedgerAsset    = "asset"
+	LedgerIncome   = "income"
+	LedgerExpense  = "expense"
+	LedgerEquity   = "equity"
+	LedgerExchange = "exchange"
+)
+
+// journalLine builds a line from a signed amount: positive amounts are debits,
+// negative amounts credits.
+func journalLine(ledger string, accountID int, name, currency string, amount models.Money) models.JournalLine {
+	line := models.JournalLine{Ledger: ledger, AccountID: accountID, Name: name, Currency: currency}
+	if amount >= 0 {
+		line.Debit = amount
+	} else {
+		line.Credit = -amount
+	}
+	return line
+}
+
+// netJournalLines folds lines posting to the same ledger, account, name and
+// currency into one and drops lines that net to zero, so that an adjustment
+// which changes nothing for a ledger does not show up on it.
+func netJournalLines(lines []models.JournalLine) []models.JournalLine {
+	type lineKey struct {
+		ledger    string
+		accountID int
+		name      string
+		currency  string
+	}
+
+	var keys []lineKey
+	net := map[lineKey]models.Money{}
+	for _, line := range lines {
+		key := lineKey{line.Ledger, line.AccountID, line.Name, line.Currency}
+		if _, ok := net[key]; !ok {
+			keys = append(keys, key)
+		}
+		net[key] += line.Debit - line.Credit
+	}
+
+	netted := make([]models.JournalLine, 0, len(keys))
+	for _, key := range keys {
+		if net[key] != 0 {
+			netted = append(netted, journalLine(key.ledger, key.accountID, key.name, key.currency, net[key]))
+		}
+	}
+	return netted
+}
+
+// postJournalEntry validates that the entry balances in every currency, writes
+// it and applies it to the cached balances: asset lines move accounts.balance
+// and income and expense lines move users.balance. It is the only place that
+// changes either cached balance. An entry whose lines all net to zero is not
+// written and is returned with a zero ID.
+func postJournalEntry(db dbExecutor, entry models.JournalEntry) (models.JournalEntry, error) {
+	for _, line := range entry.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return models.JournalEntry{}, fmt.Errorf("journal lines cannot have negative amounts")
+		}
+	}
+
+	lines := netJournalLines(entry.Lines)
+	totals := map[string]models.Money{}
+	for _, line := range lines {
+		switch line.Ledger {
+		case LedgerAsset:
+			if line.AccountID == 0 {
+				return models.JournalEntry{}, fmt.Errorf("asset journal lines need an account")
+			}
+		case LedgerIncome, LedgerExpense, LedgerEquity, LedgerExchange:
+		default:
+			return models.JournalEntry{}, fmt.Errorf("unknown ledger %s", line.Ledger)
+		}
+		if line.Currency == "" {
+			return models.JournalEntry{}, fmt.Errorf("journal lines need a currency")
+		}
+		totals[line.Currency] += line.Debit - line.Credit
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return models.JournalEntry{}, fmt.Errorf("journal entry does not balance in %s: debits exceed credits by %s", currency, total)
+		}
+	}
+	if len(lines) == 0 {
+		entry.Lines = []models.JournalLine{}
+		return entry, nil
+	}
+
+	if entry.PostedAt.IsZero() {
+		entry.PostedAt = time.Now()
+	}
+	err := db.QueryRow(`
+		INSERT INTO journal_entries (user_id, kind, reference_type, reference_id, description, posted_at, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, entry.UserID, entry.Kind, entry.ReferenceType, nullableID(entry.ReferenceID), entry.Description, entry.PostedAt, time.Now()).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return models.JournalEntry{}, fmt.Errorf("failed to record journal entry: %w", err)
+	}
+
+	var userDelta models.Money
+	for i := range lines {
+		lines[i].EntryID = entry.ID
+		err := db.QueryRow(`
+			INSERT INTO journal_lines (entry_id, ledger, account_id, name, currency, debit, credit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, entry.ID, lines[i].Ledger, nullableID(lines[i].AccountID), lines[i].Name, lines[i].Currency, lines[i].Debit, lines[i].Credit).Scan(&lines[i].ID)
+		if err != nil {
+			return models.JournalEntry{}, fmt.Errorf("failed to record journal line: %w", err)
+		}
+
+		switch lines[i].Ledger {
+		case LedgerAsset:
+			if _, err := db.Exec(`UPDATE accounts SET balance = balance + $1 WHERE id = $2`, lines[i].Debit-lines[i].Credit, lines[i].AccountID); err != nil {
+				return models.JournalEntry{}, fmt.Errorf("failed to update account balance: %w", err)
+			}
+		case LedgerIncome, LedgerExpense:
+			userDelta += lines[i].Credit - lines[i].Debit
+		}
+	}
+
+	if userDelta != 0 {
+		if _, err := db.Exec(`UPDATE users SET balance = balance + $1 WHERE id = $2`, userDelta, entry.UserID); err != nil {
+			return models.JournalEntry{}, fmt.Errorf("failed to update user balance: %w", err)
+		}
+	}
+
+	entry.Lines = lines
+	return entry, nil
+}
+
+// GetJournalEntries returns the user's journal, most recent entry first.
+func GetJournalEntries(ctx context.Context, userID int) ([]models.JournalEntry, error) {
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT e.id, e.user_id, e.kind, COALESCE(e.reference_type, ''), COALESCE(e.reference_id, 0), e.description, e.posted_at, e.created_at,
+			l.id, l.ledger, COALESCE(l.account_id, 0), l.name, l.currency, l.debit, l.credit
+		FROM journal_entries e
+		JOIN journal_lines l ON l.entry_id = e.id
+		WHERE e.user_id = $1
+		ORDER BY e.posted_at DESC, e.id DESC, l.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch journal: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.JournalEntry{}
+	for rows.Next() {
+		var entry models.JournalEntry
+		var line models.JournalLine
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Kind, &entry.ReferenceType, &entry.ReferenceID, &entry.Description, &entry.PostedAt, &entry.CreatedAt,
+			&line.ID, &line.Ledger, &line.AccountID, &line.Name, &line.Currency, &line.Debit, &line.Credit); err != nil {
+			return nil, fmt.Errorf("failed to scan journal line: %w", err)
+		}
+		line.EntryID = entry.ID
+
+		if n := len(entries); n > 0 && entries[n-1].ID == entry.ID {
+			entries[n-1].Lines = append(entries[n-1].Lines, line)
+			continue
+		}
+		entry.Lines = []models.JournalLine{line}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CheckJournalIntegrity proves that every journal entry balances per currency
+// and that the cached account and user balances equal what the journal says.
+// A userID of zero checks every user.
+func CheckJournalIntegrity(ctx context.Context, userID int) (models.JournalIntegrityReport, error) {
+	report := models.JournalIntegrityReport{
+		CheckedAt:         time.Now(),
+		UnbalancedEntries: []models.UnbalancedEntry{},
+		AccountMismatches: []models.BalanceMismatch{},
+		UserMismatches:    []models.BalanceMismatch{},
+	}
+
+	err := config.Database.QueryRowContext(ctx, `SELECT COUNT(*) FROM journal_entries WHERE $1 = 0 OR user_id = $1`, userID).Scan(&report.Entries)
+	if err != nil {
+		return report, fmt.Errorf("failed to count journal entries: %w", err)
+	}
+
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT l.entry_id, l.currency, SUM(l.debit), SUM(l.credit)
+		FROM journal_lines l
+		JOIN journal_entries e ON e.id = l.entry_id
+		WHERE $1 = 0 OR e.user_id = $1
+		GROUP BY l.entry_id, l.currency
+		HAVING SUM(l.debit) <> SUM(l.credit)
+		ORDER BY l.entry_id
+	`, userID)
+	if err != nil {
+		return report, fmt.Errorf("failed to check journal balance: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var unbalanced models.UnbalancedEntry
+		if err := rows.Scan(&unbalanced.EntryID, &unbalanced.Currency, &unbalanced.Debit, &unbalanced.Credit); err != nil {
+			return report, fmt.Errorf("failed to scan unbalanced entry: %w", err)
+		}
+		report.UnbalancedEntries = append(report.UnbalancedEntries, unbalanced)
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("failed to check journal balance: %w", err)
+	}
+
+	accountRows, err := config.Database.QueryContext(ctx, `
+		SELECT a.user_id, a.id, a.balance, COALESCE(SUM(l.debit - l.credit), 0), a.currency
+		FROM accounts a
+		LEFT JOIN journal_lines l ON l.account_id = a.id AND l.ledger = 'asset'
+		WHERE $1 = 0 OR a.user_id = $1
+		GROUP BY a.id
+		HAVING a.balance <> COALESCE(SUM(l.debit - l.credit), 0)
+		ORDER BY a.user_id, a.id
+	`, userID)
+	if err != nil {
+		return report, fmt.Errorf("failed to check account balances: %w", err)
+	}
+	defer accountRows.Close()
+	for accountRows.Next() {
+		var mismatch models.BalanceMismatch
+		if err := accountRows.Scan(&mismatch.UserID, &mismatch.AccountID, &mismatch.Cached, &mismatch.Journal, &mismatch.Currency); err != nil {
+			return report, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		report.AccountMismatches = append(report.AccountMismatches, mismatch)
+	}
+	if err := accountRows.Err(); err != nil {
+		return report, fmt.Errorf("failed to check account balances: %w", err)
+	}
+
+	// users.balance is the net of every income and expense plus the opening
+	// balance carried over from before accounts existed. Users who never used
+	// accounts have no journal yet and are skipped.
+	userRows, err := config.Database.QueryContext(ctx, `
+		SELECT u.id, u.balance, COALESCE(SUM(l.credit - l.debit), 0)
+		FROM users u
+		LEFT JOIN journal_entries e ON e.user_id = u.id
+		LEFT JOIN journal_lines l ON l.entry_id = e.id AND l.ledger IN ('income', 'expense', 'equity')
+		WHERE ($1 = 0 OR u.id = $1) AND EXISTS (SELECT 1 FROM accounts a WHERE a.user_id = u.id)
+		GROUP BY u.id
+		HAVING u.balance <> COALESCE(SUM(l.credit - l.debit), 0)
+		ORDER BY u.id
+	`, userID)
+	if err != nil {
+		return report, fmt.Errorf("failed to check user balances: %w", err)
+	}
+	defer userRows.Close()
+	for userRows.Next() {
+		var mismatch models.BalanceMismatch
+		if err := userRows.Scan(&mismatch.UserID, &mismatch.Cached, &mismatch.Journal); err != nil {
+			return report, fmt.Errorf("failed to scan user balance: %w", err)
+		}
+		report.UserMismatches = append(report.UserMismatches, mismatch)
+	}
+	if err := userRows.Err(); err != nil {
+		return report, fmt.Errorf("failed to check user balances: %w", err)
+	}
+
+	report.OK = len(report.UnbalancedEntries) == 0 && len(report.AccountMismatches) == 0 && len(report.UserMismatches) == 0
+	return report, nil
+}
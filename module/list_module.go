@@ -0,0 +1,332 @@
+package module
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+const (
+	ListOrderAsc  = "asc"
+	ListOrderDesc = "desc"
+)
+
+// listQueryError reports a list query that cannot be served, such as an
+// unknown sort field, as opposed to a database failure.
+type listQueryError struct {
+	message string
+}
+
+func (e *listQueryError) Error() string {
+	return e.message
+}
+
+func listQueryErrorf(format string, args ...interface{}) error {
+	return &listQueryError{message: fmt.Sprintf(format, args...)}
+}
+
+// IsListQueryError tells whether a list function failed because of its query
+// rather than the database.
+func IsListQueryError(err error) bool {
+	var queryErr *listQueryError
+	return errors.As(err, &queryErr)
+}
+
+// listSpec describes how a list endpoint filters, sorts and pages one table.
+// A criterion whose column is empty is not supported by the list.
+type listSpec[T any] struct {
+	name    string
+	table   string
+	columns string
+	scan    func(row interface{ Scan(...interface{}) error }) (T, error)
+	id      func(T) int
+
+	userColumn     string
+	dateColumn     string
+	amountColumn   string
+	categoryColumn string
+	searchColumns  []string
+	tagOwner       string
+
+	sorts        map[string]listSort[T]
+	defaultSort  string
+	defaultOrder string
+}
+
+// listSort is a sort field. Its value renders a row's sort key as text that
+// casts back to the column's type, for keyset cursors.
+type listSort[T any] struct {
+	column string
+	cast   string
+	value  func(T) string
+}
+
+// listCursor points just past the last row of a page. It records the sort so
+// a cursor cannot be replayed against a different ordering.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"i"`
+}
+
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(raw string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return listCursor{}, listQueryErrorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// listArgs collects positional query arguments.
+type listArgs []interface{}
+
+func (a *listArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// sortOf resolves the query's sort field and order against the spec.
+func (spec listSpec[T]) sortOf(query models.ListQuery) (string, listSort[T], string, error) {
+	name := query.Sort
+	if name == "" {
+		name = spec.defaultSort
+	}
+	field, ok := spec.sorts[name]
+	if !ok {
+		return "", listSort[T]{}, "", listQueryErrorf("%s cannot be sorted by %s, use one of %s", spec.name, name, strings.Join(spec.sortNames(), ", "))
+	}
+
+	order := strings.ToLower(query.Order)
+	switch order {
+	case "":
+		order = spec.defaultOrder
+	case ListOrderAsc, ListOrderDesc:
+	default:
+		return "", listSort[T]{}, "", listQueryErrorf("order must be asc or desc")
+	}
+
+	return name, field, order, nil
+}
+
+func (spec listSpec[T]) sortNames() []string {
+	names := make([]string, 0, len(spec.sorts))
+	for name := range spec.sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filter returns the WHERE conditions of the query, without the cursor.
+func (spec listSpec[T]) filter(userID int, query models.ListQuery, args *listArgs) ([]string, error) {
+	var conditions []string
+	if spec.userColumn != "" {
+		conditions = append(conditions, spec.userColumn+" = "+args.add(userID))
+	}
+
+	if query.From != nil || query.To != nil {
+		if spec.dateColumn == "" {
+			return nil, listQueryErrorf("%s cannot be filtered by date", spec.name)
+		}
+		if query.From != nil {
+			conditions = append(conditions, spec.dateColumn+" >= "+args.add(*query.From))
+		}
+		if query.To != nil {
+			conditions = append(conditions, spec.dateColumn+" < "+args.add(*query.To))
+		}
+	}
+
+	if query.MinAmount != nil || query.MaxAmount != nil {
+		if spec.amountColumn == "" {
+			return nil, listQueryErrorf("%s cannot be filtered by amount", spec.name)
+		}
+		if query.MinAmount != nil {
+			conditions = append(conditions, spec.amountColumn+" >= "+args.add(*query.MinAmount))
+		}
+		if query.MaxAmount != nil {
+			conditions = append(conditions, spec.amountColumn+" <= "+args.add(*query.MaxAmount))
+		}
+	}
+
+	if category := strings.TrimSpace(query.Category); category != "" {
+		if spec.categoryColumn == "" {
+			return nil, listQueryErrorf("%s cannot be filtered by category", spec.name)
+		}
+		conditions = append(conditions, "LOWER(TRIM("+spec.categoryColumn+")) = LOWER("+args.add(category)+")")
+	}
+
+	if search := strings.TrimSpace(query.Search); search != "" {
+		if len(spec.searchColumns) == 0 {
+			return nil, listQueryErrorf("%s cannot be searched", spec.name)
+		}
+		pattern := args.add("%" + escapeLike(search) + "%")
+		matches := make([]string, len(spec.searchColumns))
+		for i, column := range spec.searchColumns {
+			matches[i] = column + " ILIKE " + pattern
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+
+	if len(query.Tags.Tags) > 0 {
+		if spec.tagOwner == "" {
+			return nil, listQueryErrorf("%s cannot be filtered by tag", spec.name)
+		}
+		clause, tagArgs := tagFilterClause(spec.tagOwner, "id", query.Tags, len(*args)+1)
+		*args = append(*args, tagArgs...)
+		conditions = append(conditions, strings.TrimPrefix(clause, " AND "))
+	}
+
+	return conditions, nil
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// stream calls fn for each row matching the query, in the query's order,
+// starting after its cursor or at its offset and stopping after its limit.
+func (spec listSpec[T]) stream(ctx context.Context, userID int, query models.ListQuery, fn func(T) error) error {
+	sortName, field, order, err := spec.sortOf(query)
+	if err != nil {
+		return err
+	}
+
+	var args listArgs
+	conditions, err := spec.filter(userID, query, &args)
+	if err != nil {
+		return err
+	}
+
+	comparison := ">"
+	if order == ListOrderDesc {
+		comparison = "<"
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeListCursor(query.Cursor)
+		if err != nil {
+			return err
+		}
+		if cursor.Sort != sortName || cursor.Order != order {
+			return listQueryErrorf("cursor does not match the sort order")
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			field.column, comparison, args.add(cursor.Value), field.cast, args.add(cursor.ID)))
+	}
+
+	sql := `SELECT ` + spec.columns + ` FROM ` + spec.table + whereClause(conditions) +
+		` ORDER BY ` + field.column + ` ` + order + `, id ` + order
+	if query.Limit > 0 {
+		sql += ` LIMIT ` + args.add(query.Limit)
+	}
+	if query.Cursor == "" && query.Offset > 0 {
+		sql += ` OFFSET ` + args.add(query.Offset)
+	}
+
+	rows, err := config.Database.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", spec.name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row, err := spec.scan(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", spec.name, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", spec.name, err)
+	}
+
+	return nil
+}
+
+// page returns one page of the rows matching the query with the total number
+// of matches and a cursor for the next page. The limit defaults to
+// DefaultListLimit and is capped at MaxListLimit.
+func (spec listSpec[T]) page(ctx context.Context, userID int, query models.ListQuery) ([]T, models.Page, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultListLimit
+	}
+	if query.Limit > MaxListLimit {
+		query.Limit = MaxListLimit
+	}
+	if query.Offset < 0 {
+		return nil, models.Page{}, listQueryErrorf("offset cannot be negative")
+	}
+
+	sortName, field, order, err := spec.sortOf(query)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+
+	var args listArgs
+	conditions, err := spec.filter(userID, query, &args)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+	page := models.Page{Limit: query.Limit, Sort: sortName, Order: order}
+	if query.Cursor == "" {
+		page.Offset = query.Offset
+	}
+	if err := config.Database.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+spec.table+whereClause(conditions), args...).Scan(&page.Total); err != nil {
+		return nil, models.Page{}, fmt.Errorf("failed to count %s: %w", spec.name, err)
+	}
+
+	// One extra row tells whether another page follows.
+	limit := query.Limit
+	query.Limit++
+	rows := []T{}
+	err = spec.stream(ctx, userID, query, func(row T) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		page.HasMore = true
+		page.NextCursor = encodeListCursor(listCursor{Sort: sortName, Order: order, Value: field.value(last), ID: spec.id(last)})
+		if query.Cursor == "" {
+			next := query.Offset + limit
+			page.NextOffset = &next
+		}
+	}
+
+	return rows, page, nil
+}
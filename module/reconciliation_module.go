@@ -0,0 +1,220 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+// balanceDiscrepancyQuery compares each user's cached balance with the balance
+// recomputed from the ledger rows, SUM(incomes) - SUM(transactions), and with
+// what the journal holds for users who have one. $1 = 0 covers every user.
+const balanceDiscrepancyQuery = `
+	SELECT u.id, u.name, u.balance, ledger.balance, journal.balance
+	FROM users u
+	CROSS JOIN LATERAL (
+		SELECT COALESCE((SELECT SUM(amount) FROM incomes WHERE user_id = u.id), 0)
+			- COALESCE((SELECT SUM(amount) FROM transactions WHERE user_id = u.id), 0) AS balance
+	) ledger
+	CROSS JOIN LATERAL (
+		SELECT SUM(l.credit - l.debit) AS balance
+		FROM journal_entries e
+		JOIN journal_lines l ON l.entry_id = e.id AND l.ledger IN ('income', 'expense', 'equity')
+		WHERE e.user_id = u.id
+	) journal
+	WHERE ($1 = 0 OR u.id = $1)
+		AND (u.balance <> ledger.balance OR journal.balance <> ledger.balance)
+	ORDER BY u.id
+`
+
+func scanBalanceDiscrepancy(row interface{ Scan(...interface{}) error }) (models.BalanceDiscrepancy, error) {
+	var discrepancy models.BalanceDiscrepancy
+	var journal *models.Money
+	err := row.Scan(&discrepancy.UserID, &discrepancy.Name, &discrepancy.Cached, &discrepancy.Computed, &journal)
+	discrepancy.Journal = journal
+	return discrepancy, err
+}
+
+// FindBalanceDiscrepancies lists the users whose cached balance, or journal,
+// disagrees with SUM(incomes) - SUM(transactions).
+func FindBalanceDiscrepancies(ctx context.Context) ([]models.BalanceDiscrepancy, error) {
+	rows, err := config.Database.QueryContext(ctx, balanceDiscrepancyQuery, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+	defer rows.Close()
+
+	discrepancies := []models.BalanceDiscrepancy{}
+	for rows.Next() {
+		discrepancy, err := scanBalanceDiscrepancy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance discrepancy: %w", err)
+		}
+		discrepancies = append(discrepancies, discrepancy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to reconcile balances: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+// RepairBalance sets the user's cached balance to SUM(incomes) -
+// SUM(transactions) and records an audit entry. When the journal disagrees as
+// well, the difference is posted to the default account against the equity
+// ledger so that the journal keeps matching the cached balances. It returns a
+// nil reconciliation when there is nothing to repair.
+func RepairBalance(ctx context.Context, userID, adminID int, reason string) (*models.BalanceReconciliation, error) {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	if _, err := db.Exec(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	discrepancy, err := scanBalanceDiscrepancy(db.QueryRow(balanceDiscrepancyQuery, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile balance: %w", err)
+	}
+
+	reconciliation := models.BalanceReconciliation{
+		UserID:          userID,
+		PreviousBalance: discrepancy.Cached,
+		Balance:         discrepancy.Computed,
+		Difference:      discrepancy.Computed - discrepancy.Cached,
+		Reason:          reason,
+		FixedBy:         adminID,
+	}
+
+	if discrepancy.Journal != nil && *discrepancy.Journal != discrepancy.Computed {
+		accountID, err := ensureDefaultAccount(db, userID)
+		if err != nil {
+			return nil, err
+		}
+		account, err := lockAccount(db, accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		correction := discrepancy.Computed - *discrepancy.Journal
+		entry, err := postJournalEntry(db, models.JournalEntry{
+			UserID:      userID,
+			Kind:        JournalKindAdjustment,
+			Description: "Balance reconciliation: " + reason,
+			Lines: []models.JournalLine{
+				journalLine(LedgerAsset, accountID, "", account.Currency, correction),
+				journalLine(LedgerEquity, 0, "Balance reconciliation", account.Currency, -correction),
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to post balance correction: %w", err)
+		}
+		reconciliation.JournalEntryID = &entry.ID
+	}
+
+	if _, err := db.Exec(`UPDATE users SET balance = $1 WHERE id = $2`, discrepancy.Computed, userID); err != nil {
+		return nil, fmt.Errorf("failed to correct user balance: %w", err)
+	}
+
+	err = db.QueryRow(`
+		INSERT INTO balance_reconciliations (user_id, previous_balance, balance, difference, journal_entry_id, reason, fixed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, userID, reconciliation.PreviousBalance, reconciliation.Balance, reconciliation.Difference, reconciliation.JournalEntryID,
+		reason, adminID, time.Now()).Scan(&reconciliation.ID, &reconciliation.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record balance reconciliation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit balance reconciliation: %w", err)
+	}
+
+	return &reconciliation, nil
+}
+
+// RepairBalances repairs every user with a discrepancy, or only the given
+// users, and returns the audit entries it wrote.
+func RepairBalances(ctx context.Context, userIDs []int, adminID int, reason string) ([]models.BalanceReconciliation, error) {
+	if len(userIDs) == 0 {
+		discrepancies, err := FindBalanceDiscrepancies(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, discrepancy := range discrepancies {
+			userIDs = append(userIDs, discrepancy.UserID)
+		}
+	}
+
+	reconciliations := []models.BalanceReconciliation{}
+	for _, userID := range userIDs {
+		reconciliation, err := RepairBalance(ctx, userID, adminID, reason)
+		if err != nil {
+			return reconciliations, fmt.Errorf("user %d: %w", userID, err)
+		}
+		if reconciliation != nil {
+			reconciliations = append(reconciliations, *reconciliation)
+		}
+	}
+
+	return reconciliations, nil
+}
+
+func GetBalanceReconciliations(ctx context.Context) ([]models.BalanceReconciliation, error) {
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT id, user_id, previous_balance, balance, difference, journal_entry_id, reason, fixed_by, created_at
+		FROM balance_reconciliations ORDER BY created_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance reconciliations: %w", err)
+	}
+	defer rows.Close()
+
+	reconciliations := []models.BalanceReconciliation{}
+	for rows.Next() {
+		var reconciliation models.BalanceReconciliation
+		if err := rows.Scan(&reconciliation.ID, &reconciliation.UserID, &reconciliation.PreviousBalance, &reconciliation.Balance, &reconciliation.Difference,
+			&reconciliation.JournalEntryID, &reconciliation.Reason, &reconciliation.FixedBy, &reconciliation.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan balance reconciliation: %w", err)
+		}
+		reconciliations = append(reconciliations, reconciliation)
+	}
+
+	return reconciliations, nil
+}
+
+// StartBalanceReconciler periodically logs balance discrepancies until ctx is
+// cancelled. It only reports; repairs go through the admin endpoint.
+func StartBalanceReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		discrepancies, err := FindBalanceDiscrepancies(ctx)
+		if err != nil {
+			slog.Error("Balance reconciler error", "error", err)
+		}
+		for _, discrepancy := range discrepancies {
+			slog.Warn("Balance discrepancy", "user_id", discrepancy.UserID, "cached", discrepancy.Cached.String(), "computed", discrepancy.Computed.String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,508 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyCron    = "cron"
+
+	OccurrenceScheduled = "scheduled"
+	OccurrencePosted    = "posted"
+	OccurrenceSkipped   = "skipped"
+	OccurrenceFailed    = "failed"
+)
+
+const recurringRuleColumns = `id, user_id, COALESCE(account_id, 0), kind, amount, category, description, frequency, interval, cron_expr, start_at, end_at, next_run_at, paused, created_at`
+
+func scanRecurringRule(row interface{ Scan(...interface{}) error }) (models.RecurringRule, error) {
+	var rule models.RecurringRule
+	var endAt, nextRunAt sql.NullTime
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.AccountID, &rule.Kind, &rule.Amount, &rule.Category, &rule.Description,
+		&rule.Frequency, &rule.Interval, &rule.CronExpr, &rule.StartAt, &endAt, &nextRunAt, &rule.Paused, &rule.CreatedAt)
+	if endAt.Valid {
+		rule.EndAt = &endAt.Time
+	}
+	if nextRunAt.Valid {
+		rule.NextRunAt = &nextRunAt.Time
+	}
+	return rule, err
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
+
+// nextOccurrence returns the first occurrence of the rule strictly after the
+// given time. Occurrences are always derived from StartAt so that monthly rules
+// starting on the 31st do not drift after a short month.
+func nextOccurrence(rule models.RecurringRule, after time.Time) (time.Time, error) {
+	start := rule.StartAt
+	interval := rule.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	if rule.Frequency == FrequencyCron {
+		schedule, err := utils.ParseCron(rule.CronExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if after.Before(start) {
+			after = start.Add(-time.Minute)
+		}
+		next := schedule.Next(after)
+		if next.IsZero() {
+			return time.Time{}, fmt.Errorf("cron expression %q never fires", rule.CronExpr)
+		}
+		return next, nil
+	}
+
+	if after.Before(start) {
+		return start, nil
+	}
+
+	switch rule.Frequency {
+	case FrequencyDaily, FrequencyWeekly:
+		step := interval
+		if rule.Frequency == FrequencyWeekly {
+			step *= 7
+		}
+		days := int(after.Sub(start).Hours()/24) / step * step
+		next := start.AddDate(0, 0, days)
+		for !next.After(after) {
+			days += step
+			next = start.AddDate(0, 0, days)
+		}
+		return next, nil
+	case FrequencyMonthly:
+		months := ((after.Year()-start.Year())*12 + int(after.Month()-start.Month())) / interval * interval
+		next := addMonthsClamped(start, months)
+		for !next.After(after) {
+			months += interval
+			next = addMonthsClamped(start, months)
+		}
+		return next, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported frequency %s", rule.Frequency)
+}
+
+func CreateRecurringRule(rule models.RecurringRule) (models.RecurringRule, error) {
+	if rule.Kind != CategoryKindExpense && rule.Kind != CategoryKindIncome {
+		return models.RecurringRule{}, fmt.Errorf("kind must be either %s or %s", CategoryKindExpense, CategoryKindIncome)
+	}
+	if rule.Amount <= 0 {
+		return models.RecurringRule{}, fmt.Errorf("amount must be greater than zero")
+	}
+	switch rule.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		rule.CronExpr = ""
+	case FrequencyCron:
+		if _, err := utils.ParseCron(rule.CronExpr); err != nil {
+			return models.RecurringRule{}, err
+		}
+	default:
+		return models.RecurringRule{}, fmt.Errorf("frequency must be one of daily, weekly, monthly or cron")
+	}
+	if rule.Interval <= 0 {
+		rule.Interval = 1
+	}
+	if rule.StartAt.IsZero() {
+		rule.StartAt = time.Now()
+	}
+	if rule.EndAt != nil && rule.EndAt.Before(rule.StartAt) {
+		return models.RecurringRule{}, fmt.Errorf("end_at must be after start_at")
+	}
+
+	accountID, err := resolveAccount(config.Database, rule.UserID, rule.AccountID)
+	if err != nil {
+		return models.RecurringRule{}, err
+	}
+	var currency string
+	if err := config.Database.QueryRow(`SELECT currency FROM accounts WHERE id = $1`, accountID).Scan(&currency); err != nil {
+		return models.RecurringRule{}, fmt.Errorf("failed to fetch account currency: %w", err)
+	}
+	if err := validateAmount(rule.Amount, currency); err != nil {
+		return models.RecurringRule{}, err
+	}
+
+	first, err := nextOccurrence(rule, rule.StartAt.Add(-time.Nanosecond))
+	if err != nil {
+		return models.RecurringRule{}, err
+	}
+	var nextRunAt *time.Time
+	if rule.EndAt == nil || !first.After(*rule.EndAt) {
+		nextRunAt = &first
+	}
+
+	query := `
+		INSERT INTO recurring_rules (user_id, account_id, kind, amount, category, description, frequency, interval, cron_expr, start_at, end_at, next_run_at, paused, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, FALSE, $13)
+		RETURNING ` + recurringRuleColumns
+	created, err := scanRecurringRule(config.Database.QueryRow(query, rule.UserID, nullableID(rule.AccountID), rule.Kind, rule.Amount, rule.Category, rule.Description,
+		rule.Frequency, rule.Interval, rule.CronExpr, rule.StartAt, rule.EndAt, nextRunAt, time.Now()))
+	if err != nil {
+		return models.RecurringRule{}, fmt.Errorf("failed to create recurring rule: %w", err)
+	}
+
+	return created, nil
+}
+
+func GetRecurringRules(userID int) ([]models.RecurringRule, error) {
+	rows, err := config.Database.Query(`SELECT `+recurringRuleColumns+` FROM recurring_rules WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recurring rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.RecurringRule{}
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recurring rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func GetRecurringRule(ruleID, userID int) (*models.RecurringRule, error) {
+	row := config.Database.QueryRow(`SELECT `+recurringRuleColumns+` FROM recurring_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	rule, err := scanRecurringRule(row)
+	if err != nil {
+		return nil, fmt.Errorf("recurring rule not found or does not belong to the user: %w", err)
+	}
+	return &rule, nil
+}
+
+// SetRecurringRulePaused pauses or resumes a rule. Resuming does not back-fill
+// the occurrences that fell due while the rule was paused.
+func SetRecurringRulePaused(ruleID, userID int, paused bool) (*models.RecurringRule, error) {
+	rule, err := GetRecurringRule(ruleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	nextRunAt := rule.NextRunAt
+	if !paused && rule.Paused && nextRunAt != nil && nextRunAt.Before(time.Now()) {
+		next, err := nextOccurrence(*rule, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		nextRunAt = &next
+		if rule.EndAt != nil && next.After(*rule.EndAt) {
+			nextRunAt = nil
+		}
+	}
+
+	_, err = config.Database.Exec(`UPDATE recurring_rules SET paused = $1, next_run_at = $2 WHERE id = $3`, paused, nextRunAt, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update recurring rule: %w", err)
+	}
+
+	rule.Paused = paused
+	rule.NextRunAt = nextRunAt
+	return rule, nil
+}
+
+func DeleteRecurringRule(ruleID, userID int) error {
+	result, err := config.Database.Exec(`DELETE FROM recurring_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring rule: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("recurring rule not found or does not belong to the user")
+	}
+	return nil
+}
+
+// SkipRecurringOccurrence marks a future occurrence as skipped so the scheduler
+// never materializes it. A zero dueAt skips the next pending occurrence.
+func SkipRecurringOccurrence(ruleID, userID int, dueAt time.Time) (models.RecurringOccurrence, error) {
+	rule, err := GetRecurringRule(ruleID, userID)
+	if err != nil {
+		return models.RecurringOccurrence{}, err
+	}
+	if rule.NextRunAt == nil {
+		return models.RecurringOccurrence{}, fmt.Errorf("recurring rule has no pending occurrences")
+	}
+
+	if dueAt.IsZero() {
+		dueAt = *rule.NextRunAt
+	}
+	if dueAt.Before(*rule.NextRunAt) {
+		return models.RecurringOccurrence{}, fmt.Errorf("occurrence at %s has already been processed", dueAt.Format(time.RFC3339))
+	}
+	if rule.EndAt != nil && dueAt.After(*rule.EndAt) {
+		return models.RecurringOccurrence{}, fmt.Errorf("occurrence at %s is after the end of the rule", dueAt.Format(time.RFC3339))
+	}
+
+	expected, err := nextOccurrence(*rule, dueAt.Add(-time.Nanosecond))
+	if err != nil {
+		return models.RecurringOccurrence{}, err
+	}
+	if !expected.Equal(dueAt) {
+		return models.RecurringOccurrence{}, fmt.Errorf("no occurrence is scheduled at %s", dueAt.Format(time.RFC3339))
+	}
+
+	occurrence := models.RecurringOccurrence{RuleID: ruleID, DueAt: dueAt, Status: OccurrenceSkipped}
+	err = config.Database.QueryRow(`
+		INSERT INTO recurring_occurrences (rule_id, due_at, status, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (rule_id, due_at) DO NOTHING
+		RETURNING id, created_at
+	`, ruleID, dueAt, OccurrenceSkipped, time.Now()).Scan(&occurrence.ID, &occurrence.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RecurringOccurrence{}, fmt.Errorf("occurrence at %s is already skipped", dueAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		return models.RecurringOccurrence{}, fmt.Errorf("failed to skip occurrence: %w", err)
+	}
+
+	return occurrence, nil
+}
+
+// GetRecurringOccurrences returns the processed occurrences of a rule, most
+// recent first, and the next upcoming ones.
+func GetRecurringOccurrences(ruleID, userID, upcoming int) ([]models.RecurringOccurrence, []models.RecurringOccurrence, error) {
+	rule, err := GetRecurringRule(ruleID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := config.Database.Query(`
+		SELECT id, rule_id, due_at, status, entry_id, COALESCE(error, ''), created_at
+		FROM recurring_occurrences WHERE rule_id = $1 ORDER BY due_at DESC
+	`, ruleID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.RecurringOccurrence{}
+	skipped := map[int64]bool{}
+	for rows.Next() {
+		var occurrence models.RecurringOccurrence
+		var entryID sql.NullInt64
+		if err := rows.Scan(&occurrence.ID, &occurrence.RuleID, &occurrence.DueAt, &occurrence.Status, &entryID, &occurrence.Error, &occurrence.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan occurrence: %w", err)
+		}
+		if entryID.Valid {
+			id := int(entryID.Int64)
+			occurrence.EntryID = &id
+		}
+		if occurrence.Status == OccurrenceSkipped && rule.NextRunAt != nil && !occurrence.DueAt.Before(*rule.NextRunAt) {
+			skipped[occurrence.DueAt.Unix()] = true
+			continue
+		}
+		history = append(history, occurrence)
+	}
+
+	next := []models.RecurringOccurrence{}
+	if rule.NextRunAt != nil {
+		due := *rule.NextRunAt
+		for len(next) < upcoming && (rule.EndAt == nil || !due.After(*rule.EndAt)) {
+			status := OccurrenceScheduled
+			if skipped[due.Unix()] {
+				status = OccurrenceSkipped
+			}
+			next = append(next, models.RecurringOccurrence{RuleID: ruleID, DueAt: due, Status: status})
+			if due, err = nextOccurrence(*rule, due); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return history, next, nil
+}
+
+// RunDueRecurringRules materializes every occurrence that is due at now. Each
+// occurrence is claimed in recurring_occurrences (unique per rule and due time)
+// in the same database transaction that creates the income or expense, so an
+// occurrence is posted exactly once even if the server restarts or several
+// instances run the scheduler.
+func RunDueRecurringRules(now time.Time) (int, error) {
+	rows, err := config.Database.Query(`SELECT id FROM recurring_rules WHERE NOT paused AND next_run_at IS NOT NULL AND next_run_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch due recurring rules: %w", err)
+	}
+
+	var ruleIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan recurring rule: %w", err)
+		}
+		ruleIDs = append(ruleIDs, id)
+	}
+	rows.Close()
+
+	posted := 0
+	for _, ruleID := range ruleIDs {
+		count, err := runRecurringRule(ruleID, now)
+		if err != nil {
+			slog.Error("Error running recurring rule", "rule_id", ruleID, "error", err)
+			continue
+		}
+		posted += count
+	}
+
+	return posted, nil
+}
+
+func runRecurringRule(ruleID int, now time.Time) (int, error) {
+	tx, err := config.Database.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rule, err := scanRecurringRule(tx.QueryRow(`
+		SELECT `+recurringRuleColumns+` FROM recurring_rules
+		WHERE id = $1 AND NOT paused AND next_run_at IS NOT NULL AND next_run_at <= $2
+		FOR UPDATE SKIP LOCKED
+	`, ruleID, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock recurring rule: %w", err)
+	}
+
+	posted := 0
+	due := *rule.NextRunAt
+	var nextRunAt *time.Time
+	for {
+		if rule.EndAt != nil && due.After(*rule.EndAt) {
+			nextRunAt = nil
+			break
+		}
+		if due.After(now) {
+			nextRunAt = &due
+			break
+		}
+
+		ok, err := postRecurringOccurrence(tx, rule, due, now)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			posted++
+		}
+
+		if due, err = nextOccurrence(rule, due); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE recurring_rules SET next_run_at = $1 WHERE id = $2`, nextRunAt, rule.ID); err != nil {
+		return 0, fmt.Errorf("failed to advance recurring rule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit recurring rule: %w", err)
+	}
+	if rule.Kind == CategoryKindIncome {
+		metrics.IncomesCreated(metrics.SourceRecurring, posted)
+	} else {
+		metrics.ExpensesCreated(metrics.SourceRecurring, posted)
+	}
+
+	return posted, nil
+}
+
+// postRecurringOccurrence claims the occurrence and creates its ledger entry.
+// An occurrence that was already claimed (posted before a restart, or skipped
+// by the user) is left untouched. A ledger error such as insufficient funds is
+// recorded on the occurrence instead of aborting the whole rule.
+func postRecurringOccurrence(tx *sql.Tx, rule models.RecurringRule, due, now time.Time) (bool, error) {
+	var occurrenceID int
+	err := tx.QueryRow(`
+		INSERT INTO recurring_occurrences (rule_id, due_at, status, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (rule_id, due_at) DO NOTHING
+		RETURNING id
+	`, rule.ID, due, OccurrencePosted, now).Scan(&occurrenceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim occurrence: %w", err)
+	}
+
+	if _, err := tx.Exec(`SAVEPOINT recurring_occurrence`); err != nil {
+		return false, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	var entryID int
+	if rule.Kind == CategoryKindIncome {
+		var income models.Income
+		income, err = createIncome(tx, rule.UserID, rule.AccountID, rule.Amount, rule.Category, due)
+		entryID = income.ID
+	} else {
+		var transaction models.Transaction
+		transaction, err = createTransaction(tx, rule.UserID, rule.AccountID, rule.Amount, rule.Category, rule.Description, due)
+		entryID = transaction.ID
+	}
+
+	if err != nil {
+		if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT recurring_occurrence`); rbErr != nil {
+			return false, fmt.Errorf("failed to roll back occurrence: %w", rbErr)
+		}
+		_, updateErr := tx.Exec(`UPDATE recurring_occurrences SET status = $1, error = $2 WHERE id = $3`, OccurrenceFailed, err.Error(), occurrenceID)
+		if updateErr != nil {
+			return false, fmt.Errorf("failed to record failed occurrence: %w", updateErr)
+		}
+		slog.Warn("Recurring occurrence failed", "rule_id", rule.ID, "due_at", due.Format(time.RFC3339), "error", err)
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE recurring_occurrences SET entry_id = $1 WHERE id = $2`, entryID, occurrenceID); err != nil {
+		return false, fmt.Errorf("failed to link occurrence: %w", err)
+	}
+
+	return true, nil
+}
+
+// StartRecurringScheduler runs RunDueRecurringRules every interval until ctx is
+// cancelled.
+func StartRecurringScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		posted, err := RunDueRecurringRules(time.Now())
+		if err != nil {
+			slog.Error("Recurring scheduler error", "error", err)
+		} else if posted > 0 {
+			slog.Info("Recurring scheduler posted occurrences", "count", posted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
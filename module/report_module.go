@@ -0,0 +1,371 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+const (
+	ReportGranularityDay   = "day"
+	ReportGranularityWeek  = "week"
+	ReportGranularityMonth = "month"
+	ReportGranularityYear  = "year"
+)
+
+// reportRange is a half-open [from, to) interval of whole days in loc.
+type reportRange struct {
+	from time.Time
+	to   time.Time
+	loc  *time.Location
+}
+
+// parseReportRange reads from and to as inclusive YYYY-MM-DD dates in the
+// timezone. Without from, the range covers a default number of periods of the
+// granularity ending with to, which defaults to today.
+func parseReportRange(from, to, timezone, granularity string) (reportRange, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return reportRange{}, fmt.Errorf("unknown timezone %s", timezone)
+	}
+
+	r := reportRange{loc: loc}
+	if to == "" {
+		now := time.Now().In(loc)
+		r.to = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	} else {
+		end, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return reportRange{}, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+		r.to = end.AddDate(0, 0, 1)
+	}
+
+	if from == "" {
+		last := r.to.AddDate(0, 0, -1)
+		switch granularity {
+		case ReportGranularityDay:
+			r.from = last.AddDate(0, 0, -29)
+		case ReportGranularityWeek:
+			r.from = truncatePeriod(last, ReportGranularityWeek).AddDate(0, 0, -7*11)
+		case ReportGranularityYear:
+			r.from = truncatePeriod(last, ReportGranularityYear).AddDate(-4, 0, 0)
+		default:
+			r.from = truncatePeriod(last, ReportGranularityMonth).AddDate(0, -11, 0)
+		}
+	} else {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return reportRange{}, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+		r.from = start
+	}
+
+	if !r.from.Before(r.to) {
+		return reportRange{}, fmt.Errorf("from must not be after to")
+	}
+	return r, nil
+}
+
+// ReportRange returns the half-open [from, to) range, in the timezone, that a
+// report queried with these parameters covers.
+func ReportRange(from, to, timezone, granularity string) (time.Time, time.Time, error) {
+	r, err := parseReportRange(from, to, timezone, granularity)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return r.from, r.to, nil
+}
+
+// truncatePeriod returns the start of the period containing t, in t's
+// location. Weeks start on Monday, as with PostgreSQL's date_trunc.
+func truncatePeriod(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case ReportGranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case ReportGranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	case ReportGranularityYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func nextPeriod(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case ReportGranularityDay:
+		return start.AddDate(0, 0, 1)
+	case ReportGranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case ReportGranularityYear:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// percentChange is the change from previous to current in percent, or nil
+// when there is nothing to compare against.
+func percentChange(current, previous models.Money) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round((current-previous).Float64()/math.Abs(previous.Float64())*10000) / 100
+	return &change
+}
+
+// savingsRate is the share of income that was not spent, in percent.
+func savingsRate(income, net models.Money) *float64 {
+	if income <= 0 {
+		return nil
+	}
+	rate := math.Round(net.Float64()/income.Float64()*10000) / 100
+	return &rate
+}
+
+func reportCurrency(db dbExecutor, userID int, currency string) (string, error) {
+	if currency == "" {
+		return reportingCurrency(db, userID)
+	}
+	return utils.NormalizeCurrency(currency)
+}
+
+// conversionDate is the date whose exchange rate values a period: its last
+// day, or today for the running period.
+func conversionDate(periodEnd time.Time) time.Time {
+	if now := time.Now(); periodEnd.After(now) {
+		return now
+	}
+	return periodEnd.Add(-time.Nanosecond)
+}
+
+// GetCashFlowReport aggregates the user's incomes and expenses per day, week,
+// month or year. Period boundaries follow the given timezone and the range is
+// widened to whole periods; amounts are summed per currency in SQL and
+// converted into the reporting currency.
+func GetCashFlowReport(ctx context.Context, userID int, granularity, from, to, timezone, currency string) (models.CashFlowReport, error) {
+	switch granularity {
+	case "":
+		granularity = ReportGranularityMonth
+	case ReportGranularityDay, ReportGranularityWeek, ReportGranularityMonth, ReportGranularityYear:
+	default:
+		return models.CashFlowReport{}, fmt.Errorf("granularity must be one of day, week, month or year")
+	}
+
+	r, err := parseReportRange(from, to, timezone, granularity)
+	if err != nil {
+		return models.CashFlowReport{}, err
+	}
+	db := withContext(ctx, config.Database)
+	if currency, err = reportCurrency(db, userID, currency); err != nil {
+		return models.CashFlowReport{}, err
+	}
+
+	// One period before the range is included so that the first period has
+	// something to compare against.
+	first := truncatePeriod(r.from, granularity)
+	previous := truncatePeriod(first.AddDate(0, 0, -1), granularity)
+
+	var periods []models.CashFlowPeriod
+	index := map[int64]int{}
+	for start := previous; start.Before(r.to); start = nextPeriod(start, granularity) {
+		index[start.Unix()] = len(periods)
+		periods = append(periods, models.CashFlowPeriod{PeriodStart: start, PeriodEnd: nextPeriod(start, granularity)})
+	}
+
+	converter := newCurrencyConverter(db, currency)
+	for _, table := range []string{"incomes", "transactions"} {
+		rows, err := db.Query(`
+			SELECT date_trunc($1, created_at, $2), COALESCE(currency, $3), SUM(amount)
+			FROM `+table+`
+			WHERE user_id = $4 AND created_at >= $5 AND created_at < $6
+			GROUP BY 1, 2
+		`, granularity, r.loc.String(), DefaultCurrency(), userID, previous, r.to)
+		if err != nil {
+			return models.CashFlowReport{}, fmt.Errorf("failed to aggregate %s: %w", table, err)
+		}
+
+		for rows.Next() {
+			var periodStart time.Time
+			var entryCurrency string
+			var amount models.Money
+			if err := rows.Scan(&periodStart, &entryCurrency, &amount); err != nil {
+				rows.Close()
+				return models.CashFlowReport{}, fmt.Errorf("failed to scan %s aggregate: %w", table, err)
+			}
+
+			i, ok := index[periodStart.Unix()]
+			if !ok {
+				continue
+			}
+			converted, err := converter.convert(amount, entryCurrency, conversionDate(periods[i].PeriodEnd))
+			if err != nil {
+				rows.Close()
+				return models.CashFlowReport{}, err
+			}
+			if table == "incomes" {
+				periods[i].Income += converted
+			} else {
+				periods[i].Expense += converted
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return models.CashFlowReport{}, fmt.Errorf("failed to aggregate %s: %w", table, err)
+		}
+	}
+
+	report := models.CashFlowReport{
+		Currency:    currency,
+		Granularity: granularity,
+		Timezone:    r.loc.String(),
+		From:        first,
+		To:          r.to,
+		Periods:     []models.CashFlowPeriod{},
+	}
+	for i := range periods {
+		periods[i].Income = roundToCurrency(periods[i].Income, currency)
+		periods[i].Expense = roundToCurrency(periods[i].Expense, currency)
+		periods[i].Net = periods[i].Income - periods[i].Expense
+		periods[i].SavingsRate = savingsRate(periods[i].Income, periods[i].Net)
+		if i == 0 {
+			continue
+		}
+		periods[i].IncomeChange = percentChange(periods[i].Income, periods[i-1].Income)
+		periods[i].ExpenseChange = percentChange(periods[i].Expense, periods[i-1].Expense)
+		periods[i].NetChange = percentChange(periods[i].Net, periods[i-1].Net)
+
+		report.Income += periods[i].Income
+		report.Expense += periods[i].Expense
+		report.Periods = append(report.Periods, periods[i])
+	}
+	report.Net = report.Income - report.Expense
+	report.SavingsRate = savingsRate(report.Income, report.Net)
+
+	return report, nil
+}
+
+// GetBreakdownReport totals expenses per category and incomes per source over
+// the range and compares each with the preceding range of the same length.
+func GetBreakdownReport(ctx context.Context, userID int, from, to, timezone, currency string) (models.BreakdownReport, error) {
+	r, err := parseReportRange(from, to, timezone, ReportGranularityMonth)
+	if err != nil {
+		return models.BreakdownReport{}, err
+	}
+	db := withContext(ctx, config.Database)
+	if currency, err = reportCurrency(db, userID, currency); err != nil {
+		return models.BreakdownReport{}, err
+	}
+
+	days := int(math.Round(r.to.Sub(r.from).Hours() / 24))
+	previousFrom := r.from.AddDate(0, 0, -days)
+
+	report := models.BreakdownReport{
+		Currency:     currency,
+		Timezone:     r.loc.String(),
+		From:         r.from,
+		To:           r.to,
+		PreviousFrom: previousFrom,
+	}
+
+	converter := newCurrencyConverter(db, currency)
+	if report.Expenses, err = breakdown(converter, "transactions", "category", userID, previousFrom, r.from, r.to); err != nil {
+		return models.BreakdownReport{}, err
+	}
+	if report.Incomes, err = breakdown(converter, "incomes", "source", userID, previousFrom, r.from, r.to); err != nil {
+		return models.BreakdownReport{}, err
+	}
+
+	return report, nil
+}
+
+// breakdown aggregates the entries between previousFrom and to by category,
+// telling apart those before from.
+func breakdown(converter *currencyConverter, table, column string, userID int, previousFrom, from, to time.Time) ([]models.BreakdownItem, error) {
+	// Split expenses count towards each of their categories.
+	source := table
+	if table == "transactions" {
+		source = expenseLinesTable + " AS transactions"
+	}
+
+	rows, err := converter.db.Query(`
+		SELECT MIN(TRIM(`+column+`)), COALESCE(currency, $1), created_at >= $3, SUM(amount), COUNT(*)
+		FROM `+source+`
+		WHERE user_id = $2 AND created_at >= $4 AND created_at < $5
+		GROUP BY LOWER(TRIM(`+column+`)), 2, 3
+	`, DefaultCurrency(), userID, from, previousFrom, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var items []models.BreakdownItem
+	index := map[string]int{}
+	var total models.Money
+	for rows.Next() {
+		var name, currency string
+		var current bool
+		var amount models.Money
+		var count int
+		if err := rows.Scan(&name, &currency, &current, &amount, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan %s aggregate: %w", table, err)
+		}
+
+		date := conversionDate(from)
+		if current {
+			date = conversionDate(to)
+		}
+		converted, err := converter.convert(amount, currency, date)
+		if err != nil {
+			return nil, err
+		}
+
+		key := strings.ToLower(name)
+		i, ok := index[key]
+		if !ok {
+			i = len(items)
+			index[key] = i
+			items = append(items, models.BreakdownItem{Name: name})
+		}
+		if current {
+			items[i].Amount += converted
+			items[i].Count += count
+			total += converted
+		} else {
+			items[i].Previous += converted
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to aggregate %s: %w", table, err)
+	}
+
+	result := []models.BreakdownItem{}
+	for _, item := range items {
+		item.Amount = roundToCurrency(item.Amount, converter.target)
+		item.Previous = roundToCurrency(item.Previous, converter.target)
+		if total > 0 {
+			item.Share = math.Round(item.Amount.Float64()/total.Float64()*10000) / 100
+		}
+		item.Change = percentChange(item.Amount, item.Previous)
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Amount != result[j].Amount {
+			return result[i].Amount > result[j].Amount
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
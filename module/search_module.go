@@ -0,0 +1,137 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+// Search result types are named after their tables.
+const (
+	SearchTypeTransaction = "transactions"
+	SearchTypeIncome      = "incomes"
+	SearchTypeItem        = "items"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// maxSearchTerms bounds the size of the text search query.
+const maxSearchTerms = 10
+
+// Highlights are produced with markers unlikely to appear in user text, so
+// the text can be HTML-escaped before the markers become <mark> tags.
+const (
+	searchStartMark = "<<<"
+	searchStopMark  = ">>>"
+)
+
+// searchSources are the per-type queries of a search. Each selects the type,
+// id, title, highlighted text, amount, currency, rank and date of matches for
+// user $1 and text query $2.
+var searchSources = map[string]string{
+	SearchTypeTransaction: `
+		SELECT 'transactions', id, category,
+			ts_headline('simple', description || ' ' || category, q, $3),
+			amount, COALESCE(currency, ''), ts_rank(search_vector, q), created_at
+		FROM transactions, to_tsquery('simple', $2) q
+		WHERE user_id = $1 AND search_vector @@ q`,
+	SearchTypeIncome: `
+		SELECT 'incomes', id, source,
+			ts_headline('simple', source, q, $3),
+			amount, COALESCE(currency, ''), ts_rank(search_vector, q), created_at
+		FROM incomes, to_tsquery('simple', $2) q
+		WHERE user_id = $1 AND search_vector @@ q`,
+	SearchTypeItem: `
+		SELECT 'items', id, name,
+			ts_headline('simple', name || ' ' || description, q, $3),
+			NULL::NUMERIC, '', ts_rank(search_vector, q), created_at
+		FROM items, to_tsquery('simple', $2) q
+		WHERE user_id = $1 AND search_vector @@ q`,
+}
+
+// SearchQuery turns free text into a text search query matching entries that
+// contain every word, each also as the prefix of a longer word. Punctuation
+// is dropped so user input cannot inject query operators.
+func SearchQuery(text string) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "", fmt.Errorf("search query must contain a word")
+	}
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+// Search finds the user's expenses, incomes and items containing the words of
+// text, best matches first. types restricts the search to some result types;
+// empty searches all of them.
+func Search(ctx context.Context, userID int, text string, types []string, limit int) ([]models.SearchResult, error) {
+	query, err := SearchQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	if len(types) == 0 {
+		types = []string{SearchTypeTransaction, SearchTypeIncome, SearchTypeItem}
+	}
+	var parts []string
+	seen := map[string]bool{}
+	for _, resultType := range types {
+		source, ok := searchSources[resultType]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type %s, use transactions, incomes or items", resultType)
+		}
+		if !seen[resultType] {
+			seen[resultType] = true
+			parts = append(parts, source)
+		}
+	}
+
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=20, MinWords=5", searchStartMark, searchStopMark)
+	rows, err := config.Database.QueryContext(ctx, strings.Join(parts, " UNION ALL ")+` ORDER BY 7 DESC, 8 DESC LIMIT $4`,
+		userID, query, options, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	marks := strings.NewReplacer(html.EscapeString(searchStartMark), "<mark>", html.EscapeString(searchStopMark), "</mark>")
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		var amount *models.Money
+		if err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Highlight, &amount, &result.Currency, &result.Rank, &result.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Amount = amount
+		result.Highlight = marks.Replace(html.EscapeString(strings.TrimSpace(result.Highlight)))
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,201 @@
+package module
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/lib/pq"
+)
+
+// expenseLinesTable expands every expense into its split lines, or a single
+// line in its own category when it is not split. Reports and budgets select
+// from it so that spending is counted per category rather than per receipt.
+const expenseLinesTable = `(
+	SELECT t.id, t.user_id, t.account_id, t.currency, t.created_at, t.description,
+		COALESCE(s.category, t.category) AS category, COALESCE(s.amount, t.amount) AS amount
+	FROM transactions t LEFT JOIN transaction_splits s ON s.transaction_id = t.id
+)`
+
+// ValidateSplits checks the amounts of the split lines of an expense: none, or
+// at least two, each positive in the currency's precision, adding up to the
+// expense amount.
+func ValidateSplits(amount models.Money, currency string, splits []models.TransactionSplit) error {
+	if len(splits) == 1 {
+		return fmt.Errorf("a split transaction needs at least two splits")
+	}
+
+	var total models.Money
+	for i, split := range splits {
+		if split.Amount <= 0 {
+			return fmt.Errorf("split %d: amount must be greater than zero", i+1)
+		}
+		if err := validateAmount(split.Amount, currency); err != nil {
+			return fmt.Errorf("split %d: %v", i+1, err)
+		}
+		total += split.Amount
+	}
+	if len(splits) > 0 && total != amount {
+		return fmt.Errorf("splits add up to %s but the transaction amount is %s", total, amount)
+	}
+
+	return nil
+}
+
+// normalizeSplits validates the split lines of an expense and resolves their
+// categories against the user's taxonomy.
+func normalizeSplits(db dbExecutor, userID int, amount models.Money, currency string, splits []models.TransactionSplit) ([]models.TransactionSplit, error) {
+	if err := ValidateSplits(amount, currency, splits); err != nil {
+		return nil, err
+	}
+
+	normalized := make([]models.TransactionSplit, len(splits))
+	for i, split := range splits {
+		category, err := resolveCategory(db, userID, CategoryKindExpense, split.Category)
+		if err != nil {
+			return nil, fmt.Errorf("split %d: %v", i+1, err)
+		}
+		normalized[i] = models.TransactionSplit{Category: category, Amount: split.Amount, Note: strings.TrimSpace(split.Note)}
+	}
+
+	return normalized, nil
+}
+
+// MainSplitCategory is the category of the largest split, which a split
+// transaction shows as its own.
+func MainSplitCategory(splits []models.TransactionSplit) string {
+	main := splits[0]
+	for _, split := range splits[1:] {
+		if split.Amount > main.Amount {
+			main = split
+		}
+	}
+	return main.Category
+}
+
+// categoryAmount is the part of an expense falling in one category.
+type categoryAmount struct {
+	category string
+	amount   models.Money
+}
+
+// expenseCategoryAmounts returns how much an expense adds to each of its
+// categories. Splits in the same category, ignoring case and surrounding
+// spaces, are added up so that together they count against its budget.
+func expenseCategoryAmounts(category string, amount models.Money, splits []models.TransactionSplit) []categoryAmount {
+	if len(splits) == 0 {
+		return []categoryAmount{{category, amount}}
+	}
+
+	var amounts []categoryAmount
+	index := map[string]int{}
+	for _, split := range splits {
+		key := strings.ToLower(strings.TrimSpace(split.Category))
+		if i, ok := index[key]; ok {
+			amounts[i].amount += split.Amount
+			continue
+		}
+		index[key] = len(amounts)
+		amounts = append(amounts, categoryAmount{split.Category, split.Amount})
+	}
+	return amounts
+}
+
+// expenseLines returns the expense ledger lines of an expense, one per split
+// when it is split. A negative amount produces the reversing lines.
+func expenseLines(category, currency string, amount models.Money, splits []models.TransactionSplit) []models.JournalLine {
+	if len(splits) == 0 {
+		return []models.JournalLine{journalLine(LedgerExpense, 0, category, currency, amount)}
+	}
+
+	lines := make([]models.JournalLine, 0, len(splits))
+	for _, split := range splits {
+		splitAmount := split.Amount
+		if amount < 0 {
+			splitAmount = -splitAmount
+		}
+		lines = append(lines, journalLine(LedgerExpense, 0, split.Category, currency, splitAmount))
+	}
+	return lines
+}
+
+func loadSplits(db dbExecutor, transactionID int) ([]models.TransactionSplit, error) {
+	rows, err := db.Query(`SELECT id, transaction_id, category, amount, note FROM transaction_splits WHERE transaction_id = $1 ORDER BY id`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction splits: %w", err)
+	}
+	defer rows.Close()
+
+	var splits []models.TransactionSplit
+	for rows.Next() {
+		var split models.TransactionSplit
+		if err := rows.Scan(&split.ID, &split.TransactionID, &split.Category, &split.Amount, &split.Note); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction split: %w", err)
+		}
+		splits = append(splits, split)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction splits: %w", err)
+	}
+
+	return splits, nil
+}
+
+// saveSplits replaces the split lines of a transaction.
+func saveSplits(db dbExecutor, transactionID int, splits []models.TransactionSplit) ([]models.TransactionSplit, error) {
+	if _, err := db.Exec(`DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID); err != nil {
+		return nil, fmt.Errorf("failed to replace transaction splits: %w", err)
+	}
+
+	saved := make([]models.TransactionSplit, 0, len(splits))
+	for _, split := range splits {
+		split.TransactionID = transactionID
+		err := db.QueryRow(`
+			INSERT INTO transaction_splits (transaction_id, category, amount, note) VALUES ($1, $2, $3, $4) RETURNING id
+		`, transactionID, split.Category, split.Amount, split.Note).Scan(&split.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save transaction split: %w", err)
+		}
+		saved = append(saved, split)
+	}
+
+	return saved, nil
+}
+
+// attachSplits fills in the splits of the transactions with one query.
+func attachSplits(db dbExecutor, transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	rows, err := db.Query(`
+		SELECT id, transaction_id, category, amount, note FROM transaction_splits
+		WHERE transaction_id = ANY($1) ORDER BY id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction splits: %w", err)
+	}
+	defer rows.Close()
+
+	splits := map[int][]models.TransactionSplit{}
+	for rows.Next() {
+		var split models.TransactionSplit
+		if err := rows.Scan(&split.ID, &split.TransactionID, &split.Category, &split.Amount, &split.Note); err != nil {
+			return fmt.Errorf("failed to scan transaction split: %w", err)
+		}
+		splits[split.TransactionID] = append(splits[split.TransactionID], split)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch transaction splits: %w", err)
+	}
+
+	for i := range transactions {
+		transactions[i].Splits = splits[transactions[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package module
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/go-pdf/fpdf"
+)
+
+// StatementPeriod returns the bounds of a calendar month given as YYYY-MM in
+// the timezone, defaulting to the current month in UTC.
+func StatementPeriod(month, timezone string) (time.Time, time.Time, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown timezone %s", timezone)
+	}
+
+	var start time.Time
+	if month == "" {
+		now := time.Now().In(loc)
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	} else if start, err = time.ParseInLocation("2006-01", month, loc); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("month must be in YYYY-MM format")
+	}
+
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// WriteMonthlyStatement renders a PDF statement of the user's incomes and
+// expenses between from and to, with totals per currency.
+func WriteMonthlyStatement(userID int, from, to time.Time, w io.Writer) error {
+	var name string
+	if err := config.Database.QueryRow(`SELECT name FROM users WHERE id = $1`, userID).Scan(&name); err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(fmt.Sprintf("Statement %s", from.Format("January 2006")), true)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr("Monthly statement - "+from.Format("January 2006")), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr(name), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("%s to %s (%s)", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"), from.Location()), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{28, 20, 45, 57, 16, 24}
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, title := range []string{"Date", "Type", "Category / source", "Description", "Currency", "Amount"} {
+			align := "L"
+			if i == len(widths)-1 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 7, title, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	header()
+
+	rows, err := config.Database.Query(`
+		SELECT created_at, 'income', source, '', COALESCE(currency, $4), amount FROM incomes
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		UNION ALL
+		SELECT created_at, 'expense', category, description, COALESCE(currency, $4), -amount FROM transactions
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY 1
+	`, userID, from, to, DefaultCurrency())
+	if err != nil {
+		return fmt.Errorf("failed to fetch statement entries: %w", err)
+	}
+	defer rows.Close()
+
+	type totals struct{ income, expense models.Money }
+	byCurrency := map[string]*totals{}
+	for rows.Next() {
+		var createdAt time.Time
+		var kind, label, description, currency string
+		var amount models.Money
+		if err := rows.Scan(&createdAt, &kind, &label, &description, &currency, &amount); err != nil {
+			return fmt.Errorf("failed to scan statement entry: %w", err)
+		}
+
+		if _, ok := byCurrency[currency]; !ok {
+			byCurrency[currency] = &totals{}
+		}
+		if amount >= 0 {
+			byCurrency[currency].income += amount
+		} else {
+			byCurrency[currency].expense -= amount
+		}
+
+		if pdf.GetY() > 270 {
+			pdf.AddPage()
+			header()
+		}
+		cells := []string{createdAt.In(from.Location()).Format("2006-01-02 15:04"), kind, label, description, currency, amount.String()}
+		for i, cell := range cells {
+			align := "L"
+			if i == len(widths)-1 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, tr(truncateText(pdf, cell, widths[i]-2)), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to fetch statement entries: %w", err)
+	}
+
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 8, "Summary", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	if len(currencies) == 0 {
+		pdf.CellFormat(0, 6, "No incomes or expenses in this period.", "", 1, "L", false, 0, "")
+	}
+	for _, currency := range currencies {
+		t := byCurrency[currency]
+		pdf.CellFormat(0, 6, fmt.Sprintf("%s  income %s, expenses %s, net %s", currency, t.income, t.expense, t.income-t.expense), "", 1, "L", false, 0, "")
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to render statement: %w", err)
+	}
+	return nil
+}
+
+// truncateText shortens text with an ellipsis so that it fits width.
+func truncateText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
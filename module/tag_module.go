@@ -0,0 +1,399 @@
+package module
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/lib/pq"
+)
+
+// Tagged entries are named after their tables.
+const (
+	TagOwnerTransaction = "transactions"
+	TagOwnerIncome      = "incomes"
+	TagOwnerItem        = "items"
+)
+
+const maxTagNameLength = 64
+
+// tagLinks maps a tagged table to its join table and the join table's column
+// referencing it.
+var tagLinks = map[string]struct{ table, column string }{
+	TagOwnerTransaction: {"transaction_tags", "transaction_id"},
+	TagOwnerIncome:      {"income_tags", "income_id"},
+	TagOwnerItem:        {"item_tags", "item_id"},
+}
+
+// normalizeTagName trims the name. Commas are rejected because tag filters
+// are passed as comma-separated lists.
+func normalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("tag name cannot be empty")
+	}
+	if len(name) > maxTagNameLength {
+		return "", fmt.Errorf("tag name cannot be longer than %d characters", maxTagNameLength)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("tag name cannot contain commas")
+	}
+	return name, nil
+}
+
+// ParseTagFilter reads a comma-separated list of tags and a match mode of
+// "any" (the default) or "all".
+func ParseTagFilter(tags, match string) (models.TagFilter, error) {
+	var filter models.TagFilter
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	switch strings.ToLower(match) {
+	case "", "any":
+	case "all":
+		filter.MatchAll = true
+	default:
+		return models.TagFilter{}, fmt.Errorf("tag_match must be any or all")
+	}
+
+	return filter, nil
+}
+
+// tagFilterClause returns an SQL condition restricting idColumn of the tagged
+// table to entries matching the filter, numbering its arguments from
+// firstArg. It returns an empty condition for an empty filter.
+func tagFilterClause(owner, idColumn string, filter models.TagFilter, firstArg int) (string, []interface{}) {
+	if len(filter.Tags) == 0 {
+		return "", nil
+	}
+
+	names := make([]string, len(filter.Tags))
+	for i, tag := range filter.Tags {
+		names[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+
+	link := tagLinks[owner]
+	clause := ` AND ` + idColumn + ` IN (
+		SELECT l.` + link.column + ` FROM ` + link.table + ` l JOIN tags g ON g.id = l.tag_id
+		WHERE LOWER(g.name) = ANY($` + strconv.Itoa(firstArg) + `)`
+	args := []interface{}{pq.Array(names)}
+	if filter.MatchAll {
+		clause += ` GROUP BY l.` + link.column + ` HAVING COUNT(DISTINCT LOWER(g.name)) = $` + strconv.Itoa(firstArg+1)
+		args = append(args, len(uniqueFold(names)))
+	}
+	return clause + `)`, args
+}
+
+// uniqueFold drops names that repeat an earlier one regardless of case.
+func uniqueFold(names []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, name := range names {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+func CreateTag(ctx context.Context, userID int, name string) (models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+
+	var tag models.Tag
+	err = config.Database.QueryRowContext(ctx, `
+		INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING id, user_id, name, created_at
+	`, userID, name).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Tag{}, fmt.Errorf("tag %s already exists", name)
+		}
+		return models.Tag{}, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// GetTags lists the user's tags with the number of entries carrying each.
+func GetTags(ctx context.Context, userID int) ([]models.Tag, error) {
+	rows, err := config.Database.QueryContext(ctx, `
+		SELECT g.id, g.user_id, g.name, g.created_at,
+			(SELECT COUNT(*) FROM transaction_tags WHERE tag_id = g.id)
+			+ (SELECT COUNT(*) FROM income_tags WHERE tag_id = g.id)
+			+ (SELECT COUNT(*) FROM item_tags WHERE tag_id = g.id)
+		FROM tags g WHERE g.user_id = $1 ORDER BY LOWER(g.name)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.Usage); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func RenameTag(ctx context.Context, tagID, userID int, name string) (*models.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var tag models.Tag
+	err = config.Database.QueryRowContext(ctx, `
+		UPDATE tags SET name = $1 WHERE id = $2 AND user_id = $3 RETURNING id, user_id, name, created_at
+	`, name, tagID, userID).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("tag not found or does not belong to the user")
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("tag %s already exists", name)
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+
+	return &tag, nil
+}
+
+// DeleteTag deletes a tag and removes it from every entry carrying it.
+func DeleteTag(ctx context.Context, tagID, userID int) error {
+	result, err := config.Database.ExecContext(ctx, `DELETE FROM tags WHERE id = $1 AND user_id = $2`, tagID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("tag not found or does not belong to the user")
+	}
+	return nil
+}
+
+// SetTags replaces the tags of one of the user's expenses, incomes or items.
+// Tags that do not exist yet are created. It returns the tag names as stored.
+func SetTags(ctx context.Context, userID int, owner string, ownerID int, names []string) ([]string, error) {
+	link, ok := tagLinks[owner]
+	if !ok {
+		return nil, fmt.Errorf("unknown tagged entry %q", owner)
+	}
+
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, name)
+	}
+	normalized = uniqueFold(normalized)
+
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	entry := strings.TrimSuffix(owner, "s")
+	var ownerUserID int
+	err = db.QueryRow(`SELECT user_id FROM `+owner+` WHERE id = $1 FOR UPDATE`, ownerID).Scan(&ownerUserID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && ownerUserID != userID) {
+		return nil, fmt.Errorf("%s not found or does not belong to the user", entry)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", entry, err)
+	}
+
+	if _, err := db.Exec(`DELETE FROM `+link.table+` WHERE `+link.column+` = $1`, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to replace tags: %w", err)
+	}
+
+	stored := make([]string, 0, len(normalized))
+	for _, name := range normalized {
+		if _, err := db.Exec(`INSERT INTO tags (user_id, name) VALUES ($1, $2) ON CONFLICT (user_id, LOWER(name)) DO NOTHING`, userID, name); err != nil {
+			return nil, fmt.Errorf("failed to create tag: %w", err)
+		}
+
+		var tagID int
+		if err := db.QueryRow(`SELECT id, name FROM tags WHERE user_id = $1 AND LOWER(name) = LOWER($2)`, userID, name).Scan(&tagID, &name); err != nil {
+			return nil, fmt.Errorf("failed to fetch tag: %w", err)
+		}
+		if _, err := db.Exec(`INSERT INTO `+link.table+` (`+link.column+`, tag_id) VALUES ($1, $2)`, ownerID, tagID); err != nil {
+			return nil, fmt.Errorf("failed to tag %s: %w", entry, err)
+		}
+		stored = append(stored, name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tags: %w", err)
+	}
+
+	sort.Slice(stored, func(i, j int) bool { return strings.ToLower(stored[i]) < strings.ToLower(stored[j]) })
+	return stored, nil
+}
+
+// loadTags returns the tag names of the tagged entries, keyed by entry ID.
+func loadTags(db dbExecutor, owner string, ids []int) (map[int][]string, error) {
+	tags := map[int][]string{}
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	link := tagLinks[owner]
+	rows, err := db.Query(`
+		SELECT l.`+link.column+`, g.name FROM `+link.table+` l JOIN tags g ON g.id = l.tag_id
+		WHERE l.`+link.column+` = ANY($1) ORDER BY LOWER(g.name)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags[id] = append(tags[id], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// entryTags returns the tag names of a single entry.
+func entryTags(db dbExecutor, owner string, ownerID int) ([]string, error) {
+	link := tagLinks[owner]
+	rows, err := db.Query(`
+		SELECT g.name FROM `+link.table+` l JOIN tags g ON g.id = l.tag_id
+		WHERE l.`+link.column+` = $1 ORDER BY LOWER(g.name)
+	`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetTagReport totals the expenses and incomes carrying each tag over the
+// range, converted to the reporting currency. An entry with several tags
+// counts towards each of them.
+func GetTagReport(ctx context.Context, userID int, from, to, timezone, currency string) (models.TagReport, error) {
+	r, err := parseReportRange(from, to, timezone, ReportGranularityMonth)
+	if err != nil {
+		return models.TagReport{}, err
+	}
+	db := withContext(ctx, config.Database)
+	if currency, err = reportCurrency(db, userID, currency); err != nil {
+		return models.TagReport{}, err
+	}
+
+	rows, err := db.Query(`
+		SELECT g.name, TRUE, COALESCE(t.currency, $1), SUM(t.amount), COUNT(*)
+		FROM tags g JOIN transaction_tags l ON l.tag_id = g.id JOIN transactions t ON t.id = l.transaction_id
+		WHERE g.user_id = $2 AND t.created_at >= $3 AND t.created_at < $4
+		GROUP BY g.id, g.name, 3
+		UNION ALL
+		SELECT g.name, FALSE, COALESCE(i.currency, $1), SUM(i.amount), COUNT(*)
+		FROM tags g JOIN income_tags l ON l.tag_id = g.id JOIN incomes i ON i.id = l.income_id
+		WHERE g.user_id = $2 AND i.created_at >= $3 AND i.created_at < $4
+		GROUP BY g.id, g.name, 3
+	`, DefaultCurrency(), userID, r.from, r.to)
+	if err != nil {
+		return models.TagReport{}, fmt.Errorf("failed to aggregate tags: %w", err)
+	}
+	defer rows.Close()
+
+	converter := newCurrencyConverter(db, currency)
+	totals := map[string]*models.TagTotal{}
+	for rows.Next() {
+		var name, entryCurrency string
+		var expense bool
+		var amount models.Money
+		var count int
+		if err := rows.Scan(&name, &expense, &entryCurrency, &amount, &count); err != nil {
+			return models.TagReport{}, fmt.Errorf("failed to scan tag aggregate: %w", err)
+		}
+
+		converted, err := converter.convert(amount, entryCurrency, conversionDate(r.to))
+		if err != nil {
+			return models.TagReport{}, err
+		}
+
+		total, ok := totals[name]
+		if !ok {
+			total = &models.TagTotal{Tag: name}
+			totals[name] = total
+		}
+		if expense {
+			total.Expense += converted
+			total.ExpenseCount += count
+		} else {
+			total.Income += converted
+			total.IncomeCount += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.TagReport{}, fmt.Errorf("failed to aggregate tags: %w", err)
+	}
+
+	report := models.TagReport{
+		Currency: currency,
+		Timezone: r.loc.String(),
+		From:     r.from,
+		To:       r.to,
+		Tags:     []models.TagTotal{},
+	}
+	for _, total := range totals {
+		total.Expense = roundToCurrency(total.Expense, currency)
+		total.Income = roundToCurrency(total.Income, currency)
+		total.Net = total.Income - total.Expense
+		report.Tags = append(report.Tags, *total)
+	}
+	sort.Slice(report.Tags, func(i, j int) bool {
+		a, b := report.Tags[i], report.Tags[j]
+		if a.Expense+a.Income != b.Expense+b.Income {
+			return a.Expense+a.Income > b.Expense+b.Income
+		}
+		return strings.ToLower(a.Tag) < strings.ToLower(b.Tag)
+	})
+
+	return report, nil
+}
@@ -1,269 +1,647 @@
 package module
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
 	"github.com/Sc01100100/SaveCash-API/models"
 )
 
-func CreateTransaction(userID int, amount float64, category, description string) (models.Transaction, error) {
-	queryIncome := `SELECT COALESCE(SUM(amount), 0) FROM incomes WHERE user_id = $1`
-	var totalIncome float64
-	err := config.Database.QueryRow(queryIncome, userID).Scan(&totalIncome)
+// CreateTransaction records an expense against one of the user's accounts. An
+// accountID of zero books it on the default account.
+func CreateTransaction(ctx context.Context, userID, accountID int, amount models.Money, category, description string) (models.Transaction, error) {
+	return CreateSplitTransaction(ctx, userID, accountID, amount, category, description, nil)
+}
+
+// CreateSplitTransaction records an expense divided across several categories.
+// The splits must add up to the amount; the transaction takes the category of
+// its largest split. Without splits it is a plain expense.
+func CreateSplitTransaction(ctx context.Context, userID, accountID int, amount models.Money, category, description string, splits []models.TransactionSplit) (models.Transaction, error) {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := createSplitTransaction(withContext(ctx, tx), userID, accountID, amount, category, description, splits, time.Now())
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Transaction{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	metrics.ExpensesCreated(metrics.SourceAPI, 1)
+
+	return transaction, nil
+}
+
+func createTransaction(db dbExecutor, userID, accountID int, amount models.Money, category, description string, createdAt time.Time) (models.Transaction, error) {
+	return createSplitTransaction(db, userID, accountID, amount, category, description, nil, createdAt)
+}
+
+func createSplitTransaction(db dbExecutor, userID, accountID int, amount models.Money, category, description string, splits []models.TransactionSplit, createdAt time.Time) (models.Transaction, error) {
+	if amount <= 0 {
+		return models.Transaction{}, fmt.Errorf("amount must be greater than zero")
+	}
+
+	accountID, err := resolveAccount(db, userID, accountID)
 	if err != nil {
-		return models.Transaction{}, fmt.Errorf("failed to fetch total income: %w", err)
+		return models.Transaction{}, err
 	}
-	log.Printf("Total Income for UserID %d: %.2f\n", userID, totalIncome)
 
-	queryExpense := `SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE user_id = $1`
-	var totalExpense float64
-	err = config.Database.QueryRow(queryExpense, userID).Scan(&totalExpense)
+	account, err := lockAccount(db, accountID)
 	if err != nil {
-		return models.Transaction{}, fmt.Errorf("failed to fetch total expenses: %w", err)
+		return models.Transaction{}, err
+	}
+	if err := validateAmount(amount, account.Currency); err != nil {
+		return models.Transaction{}, err
 	}
-	log.Printf("Total Expenses for UserID %d: %.2f\n", userID, totalExpense)
 
-	availableBalance := totalIncome - totalExpense
-	log.Printf("Available Balance for UserID %d: %.2f\n", userID, availableBalance)
+	if amount > account.Balance {
+		metrics.InsufficientFunds("expense")
+		return models.Transaction{}, fmt.Errorf("insufficient funds: available %s, required %s", account.Balance, amount)
+	}
 
-	if amount > availableBalance {
-		return models.Transaction{}, fmt.Errorf("insufficient funds: available %.2f, required %.2f", availableBalance, amount)
+	splits, err = normalizeSplits(db, userID, amount, account.Currency, splits)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if len(splits) > 0 {
+		category = MainSplitCategory(splits)
+	}
+	for _, line := range expenseCategoryAmounts(category, amount, splits) {
+		if err := checkEnforcedBudgets(db, userID, line.category, line.amount, account.Currency, createdAt); err != nil {
+			return models.Transaction{}, err
+		}
 	}
 
 	query := `
-		INSERT INTO transactions (user_id, amount, category, description, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, amount, category, description, created_at
+		INSERT INTO transactions (user_id, account_id, amount, currency, category, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, user_id, account_id, amount, currency, category, description, created_at
 	`
 	var transaction models.Transaction
-	err = config.Database.QueryRow(query, userID, amount, category, description, time.Now()).Scan(
-		&transaction.ID, &transaction.UserID, &transaction.Amount, &transaction.Category, &transaction.Description, &transaction.CreatedAt,
+	err = db.QueryRow(query, userID, accountID, amount, account.Currency, category, description, createdAt).Scan(
+		&transaction.ID, &transaction.UserID, &transaction.AccountID, &transaction.Amount, &transaction.Currency, &transaction.Category, &transaction.Description, &transaction.CreatedAt,
 	)
 	if err != nil {
 		return models.Transaction{}, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	_, err = config.Database.Exec(`UPDATE users SET balance = balance - $1 WHERE id = $2`, amount, userID)
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          JournalKindExpense,
+		ReferenceType: "transactions",
+		ReferenceID:   transaction.ID,
+		Description:   description,
+		PostedAt:      createdAt,
+		Lines: append(expenseLines(category, account.Currency, amount, splits),
+			journalLine(LedgerAsset, accountID, "", account.Currency, -amount),
+		),
+	})
 	if err != nil {
-		return models.Transaction{}, fmt.Errorf("failed to update user balance after transaction: %w", err)
+		return models.Transaction{}, fmt.Errorf("failed to post transaction: %w", err)
+	}
+
+	if len(splits) > 0 {
+		if transaction.Splits, err = saveSplits(db, transaction.ID, splits); err != nil {
+			return models.Transaction{}, err
+		}
 	}
 
 	return transaction, nil
 }
 
-func CreateIncome(userID int, amount float64, source string) (models.Income, error) {
+// CreateIncome records an income on one of the user's accounts. An accountID of
+// zero books it on the default account.
+func CreateIncome(ctx context.Context, userID, accountID int, amount models.Money, source string) (models.Income, error) {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Income{}, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	income, err := createIncome(withContext(ctx, tx), userID, accountID, amount, source, time.Now())
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Income{}, fmt.Errorf("failed to commit income: %w", err)
+	}
+	metrics.IncomesCreated(metrics.SourceAPI, 1)
+
+	return income, nil
+}
+
+func createIncome(db dbExecutor, userID, accountID int, amount models.Money, source string, createdAt time.Time) (models.Income, error) {
+	return recordIncome(db, userID, accountID, amount, source, createdAt, JournalKindIncome)
+}
+
+// recordIncome inserts an income and posts it to the journal as an entry of the
+// given kind, so that stock sales can be told apart from other incomes.
+func recordIncome(db dbExecutor, userID, accountID int, amount models.Money, source string, createdAt time.Time, kind string) (models.Income, error) {
 	var exists bool
-	err := config.Database.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists)
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists)
 	if err != nil {
-		log.Printf("Error checking user existence: %v\n", err)
+		slog.ErrorContext(executorContext(db), "Error checking user existence", "error", err)
 		return models.Income{}, fmt.Errorf("failed to check user existence")
 	}
 	if !exists {
-		log.Printf("User with ID %d does not exist\n", userID)
 		return models.Income{}, fmt.Errorf("user with ID %d does not exist", userID)
 	}
 
-	log.Printf("Inserting income: UserID: %d, Amount: %.2f, Source: %s\n", userID, amount, source)
+	accountID, err = resolveAccount(db, userID, accountID)
+	if err != nil {
+		return models.Income{}, err
+	}
+
+	account, err := lockAccount(db, accountID)
+	if err != nil {
+		return models.Income{}, err
+	}
+	if err := validateAmount(amount, account.Currency); err != nil {
+		return models.Income{}, err
+	}
 
 	query := `
-		INSERT INTO incomes (user_id, amount, source, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, user_id, amount, source, created_at
+		INSERT INTO incomes (user_id, account_id, amount, currency, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id, account_id, amount, currency, source, created_at
 	`
 	var income models.Income
-	err = config.Database.QueryRow(query, userID, amount, source, time.Now()).Scan(&income.ID, &income.UserID, &income.Amount, &income.Source, &income.CreatedAt)
+	err = db.QueryRow(query, userID, accountID, amount, account.Currency, source, createdAt).Scan(&income.ID, &income.UserID, &income.AccountID, &income.Amount, &income.Currency, &income.Source, &income.CreatedAt)
 	if err != nil {
-		log.Printf("Error creating income: %v\n", err)
 		return income, err
 	}
 
-	log.Printf("Income created successfully: ID: %d, UserID: %d, Amount: %.2f, Source: %s, CreatedAt: %s\n",
-		income.ID, income.UserID, income.Amount, income.Source, income.CreatedAt)
-
-	_, err = config.Database.Exec(`UPDATE users SET balance = balance + $1 WHERE id = $2`, amount, userID)
+	slog.DebugContext(executorContext(db), "Income created", "income_id", income.ID, "user_id", income.UserID)
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          kind,
+		ReferenceType: "incomes",
+		ReferenceID:   income.ID,
+		Description:   source,
+		PostedAt:      createdAt,
+		Lines: []models.JournalLine{
+			journalLine(LedgerAsset, accountID, "", account.Currency, amount),
+			journalLine(LedgerIncome, 0, source, account.Currency, -amount),
+		},
+	})
 	if err != nil {
-		log.Printf("Error updating user balance: %v\n", err)
-		return models.Income{}, fmt.Errorf("failed to update user balance")
+		return models.Income{}, fmt.Errorf("failed to post income: %w", err)
 	}
 
 	return income, nil
 }
 
-func GetTransactions(userID int) ([]models.Transaction, error) {
-	query := `SELECT id, user_id, amount, category, description, created_at FROM transactions WHERE user_id = $1`
-	rows, err := config.Database.Query(query, userID)
+// transactionList is how the expense list endpoint and exports filter, sort
+// and page expenses.
+var transactionList = listSpec[models.Transaction]{
+	name:    "transactions",
+	table:   "transactions",
+	columns: `id, user_id, COALESCE(account_id, 0), amount, COALESCE(currency, ''), category, description, created_at`,
+	scan: func(row interface{ Scan(...interface{}) error }) (models.Transaction, error) {
+		var t models.Transaction
+		err := row.Scan(&t.ID, &t.UserID, &t.AccountID, &t.Amount, &t.Currency, &t.Category, &t.Description, &t.CreatedAt)
+		return t, err
+	},
+	id: func(t models.Transaction) int { return t.ID },
+
+	userColumn:     "user_id",
+	dateColumn:     "created_at",
+	amountColumn:   "amount",
+	categoryColumn: "category",
+	searchColumns:  []string{"description"},
+	tagOwner:       TagOwnerTransaction,
+
+	sorts: map[string]listSort[models.Transaction]{
+		"created_at":  {"created_at", "timestamp", func(t models.Transaction) string { return t.CreatedAt.Format(time.RFC3339Nano) }},
+		"amount":      {"amount", "numeric", func(t models.Transaction) string { return t.Amount.String() }},
+		"category":    {"category", "text", func(t models.Transaction) string { return t.Category }},
+		"description": {"description", "text", func(t models.Transaction) string { return t.Description }},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: ListOrderDesc,
+}
+
+// GetTransactions returns one page of the user's expenses matching the query,
+// with their splits and tags.
+func GetTransactions(ctx context.Context, userID int, query models.ListQuery) ([]models.Transaction, models.Page, error) {
+	transactions, page, err := transactionList.page(ctx, userID, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		return nil, models.Page{}, err
 	}
-	defer rows.Close()
 
-	var transactions []models.Transaction
-	for rows.Next() {
-		var transaction models.Transaction
-		if err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.Amount, &transaction.Category, &transaction.Description, &transaction.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan transaction: %w", err)
-		}
-		transactions = append(transactions, transaction)
+	db := withContext(ctx, config.Database)
+	if err := attachSplits(db, transactions); err != nil {
+		return nil, models.Page{}, err
+	}
+	ids := make([]int, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
 	}
+	entryTags, err := loadTags(db, TagOwnerTransaction, ids)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+	for i := range transactions {
+		transactions[i].Tags = entryTags[transactions[i].ID]
+	}
+
+	return transactions, page, nil
+}
 
-	return transactions, nil
+// StreamTransactions calls fn for each of the user's expenses matching the
+// query without loading them all into memory. It stops at the first error fn
+// returns.
+func StreamTransactions(ctx context.Context, userID int, query models.ListQuery, fn func(models.Transaction) error) error {
+	return transactionList.stream(ctx, userID, query, fn)
 }
 
-func GetIncomes(userID int) ([]models.Income, error) {
-	query := `SELECT id, user_id, amount, source, created_at FROM incomes WHERE user_id = $1`
-	rows, err := config.Database.Query(query, userID)
+// incomeList is how the income list endpoint and exports filter, sort and
+// page incomes. Incomes have no description, so search and the category
+// filter both apply to the source.
+var incomeList = listSpec[models.Income]{
+	name:    "incomes",
+	table:   "incomes",
+	columns: `id, user_id, COALESCE(account_id, 0), amount, COALESCE(currency, ''), source, created_at`,
+	scan: func(row interface{ Scan(...interface{}) error }) (models.Income, error) {
+		var i models.Income
+		err := row.Scan(&i.ID, &i.UserID, &i.AccountID, &i.Amount, &i.Currency, &i.Source, &i.CreatedAt)
+		return i, err
+	},
+	id: func(i models.Income) int { return i.ID },
+
+	userColumn:     "user_id",
+	dateColumn:     "created_at",
+	amountColumn:   "amount",
+	categoryColumn: "source",
+	searchColumns:  []string{"source"},
+	tagOwner:       TagOwnerIncome,
+
+	sorts: map[string]listSort[models.Income]{
+		"created_at": {"created_at", "timestamp", func(i models.Income) string { return i.CreatedAt.Format(time.RFC3339Nano) }},
+		"amount":     {"amount", "numeric", func(i models.Income) string { return i.Amount.String() }},
+		"source":     {"source", "text", func(i models.Income) string { return i.Source }},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: ListOrderDesc,
+}
+
+// GetIncomes returns one page of the user's incomes matching the query, with
+// their tags.
+func GetIncomes(ctx context.Context, userID int, query models.ListQuery) ([]models.Income, models.Page, error) {
+	incomes, page, err := incomeList.page(ctx, userID, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch incomes: %w", err)
+		return nil, models.Page{}, err
 	}
-	defer rows.Close()
 
-	var incomes []models.Income
-	for rows.Next() {
-		var income models.Income
-		if err := rows.Scan(&income.ID, &income.UserID, &income.Amount, &income.Source, &income.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan income: %w", err)
-		}
-		incomes = append(incomes, income)
+	ids := make([]int, len(incomes))
+	for i, income := range incomes {
+		ids[i] = income.ID
+	}
+	entryTags, err := loadTags(withContext(ctx, config.Database), TagOwnerIncome, ids)
+	if err != nil {
+		return nil, models.Page{}, err
+	}
+	for i := range incomes {
+		incomes[i].Tags = entryTags[incomes[i].ID]
 	}
 
-	return incomes, nil
+	return incomes, page, nil
 }
 
-func DeleteTransaction(transactionID int) error {
-    var transaction models.Transaction
-    err := config.Database.QueryRow(`SELECT user_id, amount FROM transactions WHERE id = $1`, transactionID).Scan(&transaction.UserID, &transaction.Amount)
-    if err != nil {
-        return fmt.Errorf("failed to fetch transaction: %w", err)
-    }
+// StreamIncomes calls fn for each of the user's incomes matching the query
+// without loading them all into memory. It stops at the first error fn
+// returns.
+func StreamIncomes(ctx context.Context, userID int, query models.ListQuery, fn func(models.Income) error) error {
+	return incomeList.stream(ctx, userID, query, fn)
+}
 
-    _, err = config.Database.Exec(`DELETE FROM transactions WHERE id = $1`, transactionID)
-    if err != nil {
-        return fmt.Errorf("failed to delete transaction: %w", err)
-    }
+func DeleteTransaction(ctx context.Context, transactionID int) error {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
 
-    _, err = config.Database.Exec(`UPDATE users SET balance = balance + $1 WHERE id = $2`, transaction.Amount, transaction.UserID)
-    if err != nil {
-        return fmt.Errorf("failed to update user balance: %w", err)
-    }
+	var transaction models.Transaction
+	err = db.QueryRow(`SELECT user_id FROM transactions WHERE id = $1`, transactionID).Scan(&transaction.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+
+	if _, err := ensureDefaultAccount(db, transaction.UserID); err != nil {
+		return err
+	}
+
+	err = db.QueryRow(`SELECT account_id, amount, currency, category FROM transactions WHERE id = $1`, transactionID).Scan(&transaction.AccountID, &transaction.Amount, &transaction.Currency, &transaction.Category)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transaction: %w", err)
+	}
 
-    return nil
+	splits, err := loadSplits(db, transactionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM transactions WHERE id = $1`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction: %w", err)
+	}
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        transaction.UserID,
+		Kind:          JournalKindReversal,
+		ReferenceType: "transactions",
+		ReferenceID:   transactionID,
+		Description:   "Deleted expense",
+		Lines: append([]models.JournalLine{
+			journalLine(LedgerAsset, transaction.AccountID, "", transaction.Currency, transaction.Amount),
+		}, expenseLines(transaction.Category, transaction.Currency, -transaction.Amount, splits)...),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post transaction deletion: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction deletion: %w", err)
+	}
+
+	return nil
 }
 
-func UpdateTransaction(transactionID int, userID int, amount float64, category string, description string) (*models.Transaction, error) {
-    var existingTransaction models.Transaction
-    err := config.Database.QueryRow(`SELECT id, user_id, amount FROM transactions WHERE id = $1`, transactionID).Scan(&existingTransaction.ID, &existingTransaction.UserID, &existingTransaction.Amount)
-    if err != nil {
-        return nil, fmt.Errorf("transaction not found: %w", err)
-    }
-
-    if existingTransaction.UserID != userID {
-        return nil, fmt.Errorf("you are not authorized to update this transaction")
-    }
-
-    if amount <= 0 {
-        return nil, fmt.Errorf("amount must be greater than zero")
-    }
-
-    var userBalance float64
-    err = config.Database.QueryRow(`SELECT balance FROM users WHERE id = $1`, userID).Scan(&userBalance)
-    if err != nil {
-        return nil, fmt.Errorf("failed to fetch user balance: %w", err)
-    }
-
-    newBalance := userBalance - (amount - existingTransaction.Amount)
-    if newBalance < 0 {
-        return nil, fmt.Errorf("insufficient funds: available %.2f, required %.2f", userBalance, amount)
-    }
-
-    _, err = config.Database.Exec(`UPDATE transactions SET amount = $1, category = $2, description = $3 WHERE id = $4`, amount, category, description, transactionID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to update transaction: %w", err)
-    }
-
-    _, err = config.Database.Exec(`UPDATE users SET balance = $1 WHERE id = $2`, newBalance, userID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to update user balance: %w", err)
-    }
-
-    existingTransaction.Amount = amount
-    existingTransaction.Category = category
-    existingTransaction.Description = description
-    return &existingTransaction, nil
+func UpdateTransaction(ctx context.Context, transactionID int, userID int, amount models.Money, category string, description string) (*models.Transaction, error) {
+	return UpdateSplitTransaction(ctx, transactionID, userID, amount, category, description, nil)
 }
 
-func DeleteIncome(incomeID int, userID int) error {
-    var income models.Income
-    err := config.Database.QueryRow(`SELECT user_id, amount FROM incomes WHERE id = $1`, incomeID).Scan(&income.UserID, &income.Amount)
-    if err != nil {
-        return fmt.Errorf("failed to fetch income: %w", err)
-    }
+// UpdateSplitTransaction updates an expense together with its splits. Nil
+// splits keep the current ones, which then still have to add up to the amount;
+// an empty list turns the expense back into a single-category one.
+func UpdateSplitTransaction(ctx context.Context, transactionID int, userID int, amount models.Money, category string, description string, splits []models.TransactionSplit) (*models.Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
 
-    if income.UserID != userID {
-        return fmt.Errorf("you are not authorized to delete this income")
-    }
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
 
-    _, err = config.Database.Exec(`DELETE FROM incomes WHERE id = $1`, incomeID)
-    if err != nil {
-        return fmt.Errorf("failed to delete income: %w", err)
-    }
+	if _, err := ensureDefaultAccount(db, userID); err != nil {
+		return nil, err
+	}
 
-    _, err = config.Database.Exec(`UPDATE users SET balance = balance - $1 WHERE id = $2`, income.Amount, income.UserID)
-    if err != nil {
-        return fmt.Errorf("failed to update user balance: %w", err)
-    }
+	var existingTransaction models.Transaction
+	err = db.QueryRow(`SELECT id, user_id, account_id, amount, currency, category, created_at FROM transactions WHERE id = $1`, transactionID).Scan(
+		&existingTransaction.ID, &existingTransaction.UserID, &existingTransaction.AccountID, &existingTransaction.Amount, &existingTransaction.Currency, &existingTransaction.Category, &existingTransaction.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
+	}
+
+	if existingTransaction.UserID != userID {
+		return nil, fmt.Errorf("you are not authorized to update this transaction")
+	}
+
+	account, err := lockAccount(db, existingTransaction.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAmount(amount, account.Currency); err != nil {
+		return nil, err
+	}
+
+	difference := amount - existingTransaction.Amount
+	if account.Balance-difference < 0 {
+		metrics.InsufficientFunds("expense_update")
+		return nil, fmt.Errorf("insufficient funds: available %s, required %s", account.Balance, difference)
+	}
+
+	existingSplits, err := loadSplits(db, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	newSplits := existingSplits
+	if splits != nil {
+		if newSplits, err = normalizeSplits(db, userID, amount, existingTransaction.Currency, splits); err != nil {
+			return nil, err
+		}
+	} else if len(existingSplits) > 0 && amount != existingTransaction.Amount {
+		return nil, fmt.Errorf("the splits of this transaction must be updated along with its amount")
+	}
+	if len(newSplits) > 0 {
+		category = MainSplitCategory(newSplits)
+	}
+	err = checkEnforcedBudgetsOnUpdate(db, userID, existingTransaction.Currency, existingTransaction.CreatedAt,
+		expenseCategoryAmounts(existingTransaction.Category, existingTransaction.Amount, existingSplits),
+		expenseCategoryAmounts(category, amount, newSplits))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`UPDATE transactions SET amount = $1, category = $2, description = $3 WHERE id = $4`, amount, category, description, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          JournalKindAdjustment,
+		ReferenceType: "transactions",
+		ReferenceID:   transactionID,
+		Description:   description,
+		Lines: append(append(
+			expenseLines(existingTransaction.Category, existingTransaction.Currency, -existingTransaction.Amount, existingSplits),
+			expenseLines(category, existingTransaction.Currency, amount, newSplits)...),
+			journalLine(LedgerAsset, existingTransaction.AccountID, "", existingTransaction.Currency, -difference),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post transaction update: %w", err)
+	}
+
+	if splits != nil {
+		if newSplits, err = saveSplits(db, transactionID, newSplits); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction update: %w", err)
+	}
+
+	existingTransaction.Amount = amount
+	existingTransaction.Category = category
+	existingTransaction.Description = description
+	existingTransaction.Splits = newSplits
+	return &existingTransaction, nil
+}
+
+func DeleteIncome(ctx context.Context, incomeID int, userID int) error {
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
+
+	if _, err := ensureDefaultAccount(db, userID); err != nil {
+		return err
+	}
+
+	var income models.Income
+	err = db.QueryRow(`SELECT user_id, account_id, amount, currency, source FROM incomes WHERE id = $1`, incomeID).Scan(&income.UserID, &income.AccountID, &income.Amount, &income.Currency, &income.Source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch income: %w", err)
+	}
+
+	if income.UserID != userID {
+		return fmt.Errorf("you are not authorized to delete this income")
+	}
 
-    return nil
+	account, err := lockAccount(db, income.AccountID)
+	if err != nil {
+		return err
+	}
+	if account.Balance < income.Amount {
+		metrics.InsufficientFunds("income_delete")
+		return fmt.Errorf("insufficient funds: deleting this income would leave the account with %s", account.Balance-income.Amount)
+	}
+
+	_, err = db.Exec(`DELETE FROM incomes WHERE id = $1`, incomeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete income: %w", err)
+	}
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        income.UserID,
+		Kind:          JournalKindReversal,
+		ReferenceType: "incomes",
+		ReferenceID:   incomeID,
+		Description:   "Deleted income",
+		Lines: []models.JournalLine{
+			journalLine(LedgerIncome, 0, income.Source, income.Currency, income.Amount),
+			journalLine(LedgerAsset, income.AccountID, "", income.Currency, -income.Amount),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post income deletion: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit income deletion: %w", err)
+	}
+
+	return nil
 }
 
-func UpdateIncome(incomeID int, userID int, amount float64, source string) (*models.Income, error) {
-    var existingIncome models.Income
-    err := config.Database.QueryRow(`SELECT id, user_id, amount, source FROM incomes WHERE id = $1`, incomeID).Scan(&existingIncome.ID, &existingIncome.UserID, &existingIncome.Amount, &existingIncome.Source)
-    if err != nil {
-        return nil, fmt.Errorf("income not found: %w", err)
-    }
+func UpdateIncome(ctx context.Context, incomeID int, userID int, amount models.Money, source string) (*models.Income, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
 
-    if existingIncome.UserID != userID {
-        return nil, fmt.Errorf("you are not authorized to update this income")
-    }
+	tx, err := config.Database.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start database transaction: %w", err)
+	}
+	defer tx.Rollback()
+	db := withContext(ctx, tx)
 
-    if amount <= 0 {
-        return nil, fmt.Errorf("amount must be greater than zero")
-    }
+	if _, err := ensureDefaultAccount(db, userID); err != nil {
+		return nil, err
+	}
 
-    amountDifference := amount - existingIncome.Amount
+	var existingIncome models.Income
+	err = db.QueryRow(`SELECT id, user_id, account_id, amount, currency, source, created_at FROM incomes WHERE id = $1`, incomeID).Scan(
+		&existingIncome.ID, &existingIncome.UserID, &existingIncome.AccountID, &existingIncome.Amount, &existingIncome.Currency, &existingIncome.Source, &existingIncome.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("income not found: %w", err)
+	}
 
-    _, err = config.Database.Exec(`UPDATE incomes SET amount = $1, source = $2 WHERE id = $3`, amount, source, incomeID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to update income: %w", err)
-    }
+	if existingIncome.UserID != userID {
+		return nil, fmt.Errorf("you are not authorized to update this income")
+	}
 
-    _, err = config.Database.Exec(`UPDATE users SET balance = balance + $1 WHERE id = $2`, amountDifference, userID)
-    if err != nil {
-        return nil, fmt.Errorf("failed to update user balance: %w", err)
-    }
+	amountDifference := amount - existingIncome.Amount
 
-    existingIncome.Amount = amount
-    existingIncome.Source = source
-    return &existingIncome, nil
+	account, err := lockAccount(db, existingIncome.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAmount(amount, account.Currency); err != nil {
+		return nil, err
+	}
+	if account.Balance+amountDifference < 0 {
+		metrics.InsufficientFunds("income_update")
+		return nil, fmt.Errorf("insufficient funds: updating this income would leave the account with %s", account.Balance+amountDifference)
+	}
+
+	_, err = db.Exec(`UPDATE incomes SET amount = $1, source = $2 WHERE id = $3`, amount, source, incomeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update income: %w", err)
+	}
+
+	_, err = postJournalEntry(db, models.JournalEntry{
+		UserID:        userID,
+		Kind:          JournalKindAdjustment,
+		ReferenceType: "incomes",
+		ReferenceID:   incomeID,
+		Description:   source,
+		Lines: []models.JournalLine{
+			journalLine(LedgerIncome, 0, existingIncome.Source, existingIncome.Currency, existingIncome.Amount),
+			journalLine(LedgerIncome, 0, source, existingIncome.Currency, -amount),
+			journalLine(LedgerAsset, existingIncome.AccountID, "", existingIncome.Currency, amountDifference),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post income update: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit income update: %w", err)
+	}
+
+	existingIncome.Amount = amount
+	existingIncome.Source = source
+	return &existingIncome, nil
 }
 
-func GetIncomeByID(incomeID int, userID int) (*models.Income, error) {
-    var income models.Income
-    err := config.Database.QueryRow(`SELECT id, user_id, amount, source, created_at FROM incomes WHERE id = $1 AND user_id = $2`, incomeID, userID).Scan(&income.ID, &income.UserID, &income.Amount, &income.Source, &income.CreatedAt)
-    if err != nil {
-        return nil, fmt.Errorf("income not found or does not belong to the user: %w", err)
-    }
-    return &income, nil
+func GetIncomeByID(ctx context.Context, incomeID int, userID int) (*models.Income, error) {
+	var income models.Income
+	err := config.Database.QueryRowContext(ctx, `SELECT id, user_id, COALESCE(account_id, 0), amount, COALESCE(currency, ''), source, created_at FROM incomes WHERE id = $1 AND user_id = $2`, incomeID, userID).Scan(&income.ID, &income.UserID, &income.AccountID, &income.Amount, &income.Currency, &income.Source, &income.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("income not found or does not belong to the user: %w", err)
+	}
+	if income.Tags, err = entryTags(withContext(ctx, config.Database), TagOwnerIncome, incomeID); err != nil {
+		return nil, err
+	}
+	return &income, nil
 }
 
-func GetTransactionByID(transactionID int, userID int) (*models.Transaction, error) {
-    var transaction models.Transaction
-    err := config.Database.QueryRow(`SELECT id, user_id, amount, category, description, created_at FROM transactions WHERE id = $1 AND user_id = $2`, transactionID, userID).Scan(&transaction.ID, &transaction.UserID, &transaction.Amount, &transaction.Category, &transaction.Description, &transaction.CreatedAt)
-    if err != nil {
-        return nil, fmt.Errorf("transaction not found or does not belong to the user: %w", err)
-    }
-    return &transaction, nil
-}
\ No newline at end of file
+func GetTransactionByID(ctx context.Context, transactionID int, userID int) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := config.Database.QueryRowContext(ctx, `SELECT id, user_id, COALESCE(account_id, 0), amount, COALESCE(currency, ''), category, description, created_at FROM transactions WHERE id = $1 AND user_id = $2`, transactionID, userID).Scan(&transaction.ID, &transaction.UserID, &transaction.AccountID, &transaction.Amount, &transaction.Currency, &transaction.Category, &transaction.Description, &transaction.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found or does not belong to the user: %w", err)
+	}
+	db := withContext(ctx, config.Database)
+	if transaction.Splits, err = loadSplits(db, transactionID); err != nil {
+		return nil, err
+	}
+	if transaction.Tags, err = entryTags(db, TagOwnerTransaction, transactionID); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
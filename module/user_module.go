@@ -1,21 +1,24 @@
 package module
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"errors"
 
 	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/metrics"
 	"github.com/Sc01100100/SaveCash-API/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func InsertUser(name, email, password, role string) (int, error) {
+func InsertUser(ctx context.Context, name, email, password, role string) (int, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		log.Printf("Error encrypting password: %v\n", err)
+		slog.ErrorContext(ctx, "Error encrypting password", "error", err)
 		return 0, fmt.Errorf("failed to encrypt password")
 	}
 
@@ -33,67 +36,73 @@ func InsertUser(name, email, password, role string) (int, error) {
 
 	var insertedID int
 
-	err = config.Database.QueryRow(query, newUser.Name, newUser.Email, newUser.Password, newUser.Role).Scan(&insertedID)
+	err = config.Database.QueryRowContext(ctx, query, newUser.Name, newUser.Email, newUser.Password, newUser.Role).Scan(&insertedID)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
-			log.Printf("InsertUser error: email %s already exists\n", newUser.Email)
+			slog.WarnContext(ctx, "Registration failed, email already exists", "email", newUser.Email)
 			return 0, fmt.Errorf("email already exists")
 		}
 
-		log.Printf("InsertUser error: %v\n", err)
+		slog.ErrorContext(ctx, "Error inserting user", "error", err)
 		return 0, fmt.Errorf("failed to insert user")
 	}
 
-	log.Printf("Inserted new user with ID: %d\n", insertedID)
+	slog.InfoContext(ctx, "Inserted new user", "user_id", insertedID)
 	return insertedID, nil
 }
 
 
-func GetAllUsers() []models.User {
-	query := `SELECT id, name, email, role FROM users`
-
-	rows, err := config.Database.Query(query)
-	if err != nil {
-		log.Printf("GetAllUsers error: %v\n", err)
-		return nil
-	}
-	defer rows.Close()
-
-	var users []models.User
-	for rows.Next() {
+// userList is how the admin user list filters, sorts and pages users.
+var userList = listSpec[models.User]{
+	name:    "users",
+	table:   "users",
+	columns: `id, name, email, role`,
+	scan: func(row interface{ Scan(...interface{}) error }) (models.User, error) {
 		var user models.User
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role)
-		if err != nil {
-			log.Printf("Row scan error: %v\n", err)
-			continue
-		}
-		users = append(users, user)
-	}
+		err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Role)
+		return user, err
+	},
+	id: func(user models.User) int { return user.ID },
+
+	searchColumns: []string{"name", "email"},
+
+	sorts: map[string]listSort[models.User]{
+		"id":    {"id", "integer", func(user models.User) string { return strconv.Itoa(user.ID) }},
+		"name":  {"name", "text", func(user models.User) string { return user.Name }},
+		"email": {"email", "text", func(user models.User) string { return user.Email }},
+	},
+	defaultSort:  "id",
+	defaultOrder: ListOrderAsc,
+}
 
-	return users
+// GetAllUsers returns one page of all users matching the query.
+func GetAllUsers(ctx context.Context, query models.ListQuery) ([]models.User, models.Page, error) {
+	return userList.page(ctx, 0, query)
 }
 
-func LoginUser(email, password string) (int, string, error) {
+func LoginUser(ctx context.Context, email, password string) (int, string, error) {
 	var user models.User
 
 	query := `SELECT id, password, role FROM users WHERE email = $1`
-	err := config.Database.QueryRow(query, email).Scan(&user.ID, &user.Password, &user.Role)
+	err := config.Database.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Password, &user.Role)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("Login failed: user with email %s not found\n", email)
+			slog.WarnContext(ctx, "Login failed, user not found", "email", email)
+			metrics.FailedLogin("unknown_email")
 			return 0, "", fmt.Errorf("user not found")
 		}
-		log.Printf("Database error during login for email %s: %v\n", email, err)
+		slog.ErrorContext(ctx, "Database error during login", "email", email, "error", err)
 		return 0, "", fmt.Errorf("database error")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		log.Printf("Login failed: invalid password for email %s\n", email)
+		slog.WarnContext(ctx, "Login failed, invalid password", "user_id", user.ID)
+		metrics.FailedLogin("invalid_password")
 		return 0, "", fmt.Errorf("invalid password")
 	}
 
 
-	log.Printf("User %d logged in successfully with role: %s\n", user.ID, user.Role)
+	slog.InfoContext(ctx, "User logged in", "user_id", user.ID)
 	return user.ID, user.Role, nil
 }
\ No newline at end of file
@@ -2,32 +2,44 @@ package routes
 
 import (
 	"github.com/Sc01100100/SaveCash-API/controllers"
+	"github.com/Sc01100100/SaveCash-API/metrics"
 	"github.com/Sc01100100/SaveCash-API/middlewares"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/swagger"
 )
 
 func SetupRoutes(app *fiber.App) {
+	app.Get("/healthz", controllers.HealthzHandler)
+	app.Get("/readyz", controllers.ReadyzHandler)
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+
 	api := app.Group("/savecash")
 
 	api.Get("/docs/*", swagger.HandlerDefault)
 	api.Post("/register", controllers.InsertUser)
 	api.Post("/login", controllers.LoginUser)
 	api.Post("/logout", controllers.LogoutUser)
+	api.Get("/attachments/:id/download", controllers.DownloadAttachmentHandler)
 
 	protected := api.Group("/", middlewares.AuthMiddleware())
 
 	protected.Post("/transactions", controllers.CreateTransactionHandler)
 	protected.Get("/transactions", controllers.GetTransactionsHandler)
+	protected.Post("/transactions/import", controllers.ImportTransactionsHandler)
 	protected.Get("/transactions/:id", controllers.GetTransactionByIDHandler)
 	protected.Put("/transactions/:id", controllers.UpdateTransactionHandler) 
 	protected.Delete("/transactions/:id", controllers.DeleteTransactionHandler) 
+	protected.Post("/transactions/:id/attachments", controllers.UploadTransactionAttachmentHandler)
+	protected.Get("/transactions/:id/attachments", controllers.GetTransactionAttachmentsHandler)
+	protected.Put("/transactions/:id/tags", controllers.SetTransactionTagsHandler)
 
 	protected.Post("/incomes", controllers.CreateIncomeHandler)
 	protected.Get("/incomes", controllers.GetIncomesHandler)
 	protected.Get("/incomes/:id", controllers.GetIncomeByIDHandler)
 	protected.Put("/incomes/:id", controllers.UpdateIncomeHandler)
 	protected.Delete("/incomes/:id", controllers.DeleteIncomeHandler)
+	protected.Put("/incomes/:id/tags", controllers.SetIncomeTagsHandler)
 
 	protected.Post("/items", controllers.AddItemHandler)            
 	protected.Get("/items", controllers.GetItemsHandler)   
@@ -35,10 +47,86 @@ func SetupRoutes(app *fiber.App) {
 	protected.Put("/items/restock/:id", controllers.RestockItemHandler)
 	protected.Put("/items/sell/:id", controllers.SellItemHandler)
 	protected.Delete("/items/:id", controllers.DeleteItemHandler)  
+	protected.Post("/items/:id/attachments", controllers.UploadItemAttachmentHandler)
+	protected.Get("/items/:id/attachments", controllers.GetItemAttachmentsHandler)
+	protected.Put("/items/:id/tags", controllers.SetItemTagsHandler)
+	protected.Delete("/attachments/:id", controllers.DeleteAttachmentHandler)
+
+	protected.Post("/budgets", controllers.CreateBudgetHandler)
+	protected.Get("/budgets", controllers.GetBudgetsHandler)
+	protected.Get("/budgets/status", controllers.GetBudgetStatusHandler)
+	protected.Put("/budgets/:id", controllers.UpdateBudgetHandler)
+	protected.Delete("/budgets/:id", controllers.DeleteBudgetHandler)
+
+	protected.Post("/categories", controllers.CreateCategoryHandler)
+	protected.Get("/categories", controllers.GetCategoriesHandler)
+	protected.Put("/categories/:id", controllers.RenameCategoryHandler)
+	protected.Post("/categories/:id/merge", controllers.MergeCategoryHandler)
+
+	protected.Post("/tags", controllers.CreateTagHandler)
+	protected.Get("/tags", controllers.GetTagsHandler)
+	protected.Put("/tags/:id", controllers.RenameTagHandler)
+	protected.Delete("/tags/:id", controllers.DeleteTagHandler)
+
+	protected.Post("/category-rules", controllers.CreateCategoryRuleHandler)
+	protected.Get("/category-rules", controllers.GetCategoryRulesHandler)
+	protected.Post("/category-rules/test", controllers.TestCategoryRuleHandler)
+	protected.Post("/category-rules/apply", controllers.ApplyCategoryRulesHandler)
+	protected.Put("/category-rules/:id", controllers.UpdateCategoryRuleHandler)
+	protected.Delete("/category-rules/:id", controllers.DeleteCategoryRuleHandler)
+
+	protected.Post("/recurring", controllers.CreateRecurringRuleHandler)
+	protected.Get("/recurring", controllers.GetRecurringRulesHandler)
+	protected.Get("/recurring/:id/occurrences", controllers.GetRecurringOccurrencesHandler)
+	protected.Put("/recurring/:id/pause", controllers.PauseRecurringRuleHandler)
+	protected.Put("/recurring/:id/resume", controllers.ResumeRecurringRuleHandler)
+	protected.Post("/recurring/:id/skip", controllers.SkipRecurringOccurrenceHandler)
+	protected.Delete("/recurring/:id", controllers.DeleteRecurringRuleHandler)
+
+	protected.Post("/accounts", controllers.CreateAccountHandler)
+	protected.Get("/accounts", controllers.GetAccountsHandler)
+	protected.Put("/accounts/:id", controllers.UpdateAccountHandler)
+	protected.Post("/transfers", controllers.CreateTransferHandler)
+	protected.Get("/transfers", controllers.GetTransfersHandler)
+	protected.Get("/exchange-rates", controllers.GetExchangeRatesHandler)
+
+	protected.Post("/goals", controllers.CreateGoalHandler)
+	protected.Get("/goals", controllers.GetGoalsHandler)
+	protected.Get("/goals/:id", controllers.GetGoalHandler)
+	protected.Put("/goals/:id", controllers.UpdateGoalHandler)
+	protected.Delete("/goals/:id", controllers.DeleteGoalHandler)
+	protected.Post("/goals/:id/contributions", controllers.AddGoalContributionHandler)
+	protected.Get("/goals/:id/suggestion", controllers.GetGoalSuggestionHandler)
+
+	protected.Post("/counterparties", controllers.CreateCounterpartyHandler)
+	protected.Get("/counterparties", controllers.GetCounterpartiesHandler)
+	protected.Post("/debts", controllers.CreateDebtHandler)
+	protected.Get("/debts", controllers.GetDebtsHandler)
+	protected.Get("/debts/summary", controllers.GetDebtSummaryHandler)
+	protected.Get("/debts/:id", controllers.GetDebtHandler)
+	protected.Get("/debts/:id/schedule", controllers.GetDebtScheduleHandler)
+	protected.Post("/debts/:id/repayments", controllers.RecordDebtRepaymentHandler)
+
+	protected.Get("/journal", controllers.GetJournalHandler)
+	protected.Get("/journal/integrity", controllers.GetJournalIntegrityHandler)
+
+	protected.Get("/reports/cashflow", controllers.GetCashFlowReportHandler)
+	protected.Get("/reports/breakdown", controllers.GetBreakdownReportHandler)
+	protected.Get("/reports/tags", controllers.GetTagReportHandler)
+
+	protected.Get("/search", controllers.SearchHandler)
+
+	protected.Get("/export/statement", controllers.ExportStatementHandler)
+	protected.Get("/export/:dataset", controllers.ExportDatasetHandler)
 
 	protected.Get("/user/info", controllers.GetUserInfo)
 
 	admin := protected.Group("/admin") 
 	admin.Use(middlewares.AdminMiddleware()) 
 	admin.Get("/users", controllers.GetAllUser) 
+	admin.Post("/exchange-rates", controllers.ImportExchangeRatesHandler)
+	admin.Get("/journal/integrity", controllers.GetAllJournalIntegrityHandler)
+	admin.Get("/reconciliation", controllers.GetBalanceDiscrepanciesHandler)
+	admin.Post("/reconciliation/repair", controllers.RepairBalancesHandler)
+	admin.Get("/reconciliation/audit", controllers.GetBalanceReconciliationsHandler)
 }
\ No newline at end of file
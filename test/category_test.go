@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func findCategory(tree []models.Category, id int) *models.Category {
+	for i := range tree {
+		if tree[i].ID == id {
+			return &tree[i]
+		}
+		if found := findCategory(tree[i].Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestMergeCategoryIntoGrandchild(t *testing.T) {
+	userID := 1
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	create := func(name string, parentID *int) models.Category {
+		category, err := module.CreateCategory(context.Background(), userID, name+" "+suffix, module.CategoryKindExpense, parentID)
+		if err != nil {
+			t.Fatalf("Failed to create category %s: %v", name, err)
+		}
+		return category
+	}
+	source := create("Source", nil)
+	child := create("Child", &source.ID)
+	sibling := create("Sibling", &source.ID)
+	target := create("Target", &child.ID)
+
+	merged, err := module.MergeCategory(context.Background(), source.ID, target.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to merge category: %v", err)
+	}
+	if merged.ParentID != nil {
+		t.Errorf("Expected the target to take the place of the source at the root, got parent %d", *merged.ParentID)
+	}
+
+	// A loop would leave the branch unreachable from the roots.
+	tree, err := module.GetCategories(context.Background(), userID, module.CategoryKindExpense)
+	if err != nil {
+		t.Fatalf("Failed to fetch categories: %v", err)
+	}
+	root := findCategory(tree, target.ID)
+	if root == nil || root.ParentID != nil {
+		t.Fatalf("Expected the target among the root categories")
+	}
+	for _, id := range []int{child.ID, sibling.ID} {
+		moved := findCategory(root.Children, id)
+		if moved == nil {
+			t.Errorf("Expected category %d to be moved under the target", id)
+		}
+	}
+	if findCategory(tree, source.ID) != nil {
+		t.Errorf("Expected the source category to be deleted")
+	}
+}
+
+func TestRenamedCategoryKeepsRulesWorking(t *testing.T) {
+	userID := 1
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	category, err := module.CreateCategory(context.Background(), userID, "Coffee "+suffix, module.CategoryKindExpense, nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	description := "espresso " + suffix
+	if _, err := module.CreateCategoryRule(userID, models.CategoryRule{Name: "Coffee " + suffix, DescriptionContains: description, Category: category.Name}); err != nil {
+		t.Fatalf("Failed to create category rule: %v", err)
+	}
+
+	renamed, err := module.RenameCategory(context.Background(), category.ID, userID, "Cafes "+suffix)
+	if err != nil {
+		t.Fatalf("Failed to rename category: %v", err)
+	}
+
+	// The same steps as creating an expense without a category.
+	ruleCategory, err := module.CategorizeTransaction(userID, 0, 0, description)
+	if err != nil {
+		t.Fatalf("Failed to categorize transaction: %v", err)
+	}
+	if ruleCategory != renamed.Name {
+		t.Fatalf("Expected the rule to follow the rename to %s, got %s", renamed.Name, ruleCategory)
+	}
+	resolved, err := module.ResolveCategory(context.Background(), userID, module.CategoryKindExpense, ruleCategory)
+	if err != nil {
+		t.Fatalf("Failed to resolve the rule's category: %v", err)
+	}
+
+	funds, _ := models.ParseMoney("5")
+	if _, err := module.CreateIncome(context.Background(), userID, 0, funds, "Rule test"); err != nil {
+		t.Fatalf("Failed to create income: %v", err)
+	}
+	transaction, err := module.CreateTransaction(context.Background(), userID, 0, funds, resolved, description)
+	if err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+	if transaction.Category != renamed.Name {
+		t.Errorf("Expected the transaction in %s, got %s", renamed.Name, transaction.Category)
+	}
+}
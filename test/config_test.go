@@ -0,0 +1,69 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/config"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := "server:\n  port: 9000\ndatabase:\n  name: fromfile\n  conn_max_lifetime: 5m\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write configuration file: %v", err)
+	}
+	t.Setenv("DB_USER", "savecash")
+	t.Setenv("DB_NAME", "fromenv")
+	t.Setenv("JWT_SECRET", "secret")
+
+	cfg, args, err := config.Load([]string{"-config", file, "-port", "9100", "migrate", "status"})
+	if err != nil {
+		t.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if cfg.Server.Port != 9100 {
+		t.Errorf("Expected the flag to override the port, got %d", cfg.Server.Port)
+	}
+	if cfg.Database.Name != "fromenv" {
+		t.Errorf("Expected the environment to override the file, got %s", cfg.Database.Name)
+	}
+	if cfg.Database.ConnMaxLifetime != 5*time.Minute {
+		t.Errorf("Expected the lifetime from the file, got %s", cfg.Database.ConnMaxLifetime)
+	}
+	if cfg.Auth.TokenTTL != 24*time.Hour {
+		t.Errorf("Expected the default token TTL, got %s", cfg.Auth.TokenTTL)
+	}
+	if cfg.Attachments.SigningKey != "secret" {
+		t.Errorf("Expected the signing key to default to the JWT secret")
+	}
+	if strings.Join(args, " ") != "migrate status" {
+		t.Errorf("Expected the remaining arguments, got %v", args)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.MaxIdleConns = 50
+	cfg.Tracing.Exporter = "jaeger"
+	cfg.Tracing.SampleRatio = 2
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected an invalid configuration")
+	}
+	for _, want := range []string{
+		"database user is required",
+		"JWT secret is required",
+		"cannot exceed max open connections",
+		"tracing exporter must be none, stdout or otlp",
+		"tracing sample ratio must be between 0 and 1",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in %v", want, err)
+		}
+	}
+}
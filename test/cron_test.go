@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 9 1 * *", time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", time.Date(2024, time.February, 1, 8, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 10 * * 7", time.Date(2024, time.February, 4, 10, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		schedule, err := utils.ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", tc.expr, err)
+			continue
+		}
+		if next := schedule.Next(from); !next.Equal(tc.expected) {
+			t.Errorf("Expected next run of %q to be %s, got %s", tc.expr, tc.expected, next)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := utils.ParseCron(expr); err == nil {
+			t.Errorf("Expected error for cron expression %q, but got none", expr)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func TestAmortizationSchedule(t *testing.T) {
+	principal, _ := models.ParseMoney("1000")
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	schedule := module.AmortizationSchedule(principal, 12, 12, start, "USD")
+	if len(schedule) != 12 {
+		t.Fatalf("Expected 12 instalments, got %d", len(schedule))
+	}
+
+	if schedule[0].Payment.String() != "88.85" || schedule[0].Interest.String() != "10" {
+		t.Errorf("Unexpected first instalment: %+v", schedule[0])
+	}
+	if !schedule[0].DueDate.Equal(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the first instalment a month after the start, got %s", schedule[0].DueDate)
+	}
+
+	var repaid models.Money
+	for _, entry := range schedule {
+		repaid += entry.Principal
+		if entry.Payment != entry.Principal+entry.Interest {
+			t.Errorf("Instalment %d does not add up: %+v", entry.Number, entry)
+		}
+	}
+	if repaid != principal || schedule[11].Balance != 0 {
+		t.Errorf("Expected the schedule to repay %s, repaid %s leaving %s", principal, repaid, schedule[11].Balance)
+	}
+}
+
+func TestAmortizationScheduleWithoutInterest(t *testing.T) {
+	principal, _ := models.ParseMoney("100")
+
+	schedule := module.AmortizationSchedule(principal, 0, 3, time.Now(), "USD")
+	if len(schedule) != 3 {
+		t.Fatalf("Expected 3 instalments, got %d", len(schedule))
+	}
+	if schedule[0].Payment.String() != "33.34" || schedule[2].Payment.String() != "33.32" {
+		t.Errorf("Unexpected instalments: %+v", schedule)
+	}
+}
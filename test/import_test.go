@@ -0,0 +1,52 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := "Date,Amount,Memo\n2024-01-02,\"-1,234.50\",Rent\n2024-01-03,2500,Salary\nsoon,abc,Broken\n"
+	mapping := models.ImportMapping{Date: "Date", Amount: "Amount", Description: "Memo"}
+
+	rows, err := module.ParseImportFile(module.ImportFormatCSV, strings.NewReader(data), mapping)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d", len(rows))
+	}
+
+	if rows[0].Kind != module.CategoryKindExpense || rows[0].Amount.String() != "1234.5" {
+		t.Errorf("Unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Kind != module.CategoryKindIncome || rows[1].Description != "Salary" {
+		t.Errorf("Unexpected second row: %+v", rows[1])
+	}
+	if rows[2].Status != module.ImportStatusInvalid || len(rows[2].Errors) != 2 {
+		t.Errorf("Expected the third row to be invalid with two errors, got %+v", rows[2])
+	}
+}
+
+func TestParseImportOFXAndQIF(t *testing.T) {
+	ofx := "OFXHEADER:100\n<OFX><BANKTRANLIST>\n<STMTTRN>\n<TRNTYPE>DEBIT\n<DTPOSTED>20240105120000[-5:EST]\n<TRNAMT>-12.34\n<NAME>Grocer\n</STMTTRN>\n</BANKTRANLIST></OFX>\n"
+	rows, err := module.ParseImportFile(module.ImportFormatOFX, strings.NewReader(ofx), models.ImportMapping{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Failed to parse OFX: %v, %+v", err, rows)
+	}
+	if rows[0].Kind != module.CategoryKindExpense || rows[0].Date.Format("2006-01-02") != "2024-01-05" {
+		t.Errorf("Unexpected OFX row: %+v", rows[0])
+	}
+
+	qif := "!Type:Bank\nD1/ 6'24\nT1,000.00\nPEmployer\n^\n"
+	rows, err = module.ParseImportFile(module.ImportFormatQIF, strings.NewReader(qif), models.ImportMapping{})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("Failed to parse QIF: %v, %+v", err, rows)
+	}
+	if rows[0].Kind != module.CategoryKindIncome || rows[0].Amount.String() != "1000" || rows[0].Date.Format("2006-01-02") != "2024-01-06" {
+		t.Errorf("Unexpected QIF row: %+v", rows[0])
+	}
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func TestListQueryRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		query models.ListQuery
+	}{
+		{"cursor that is not base64", models.ListQuery{Cursor: "not a cursor!"}},
+		{"cursor that is not JSON", models.ListQuery{Cursor: "bm90IGpzb24"}},
+		{"cursor of another sort", models.ListQuery{Cursor: "e30"}},
+		{"unknown sort", models.ListQuery{Sort: "password"}},
+		{"unknown order", models.ListQuery{Order: "sideways"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := module.StreamTransactions(context.Background(), 1, test.query, func(models.Transaction) error { return nil })
+			if !module.IsListQueryError(err) {
+				t.Errorf("Expected a list query error, got %v", err)
+			}
+		})
+	}
+
+	_, _, err := module.GetTransactions(context.Background(), 1, models.ListQuery{Offset: -1})
+	if !module.IsListQueryError(err) {
+		t.Errorf("Expected a negative offset to be rejected, got %v", err)
+	}
+}
+
+func TestListPaging(t *testing.T) {
+	ctx := context.Background()
+	userID := 1
+	category := fmt.Sprintf("paging %d", time.Now().UnixNano())
+
+	funds, _ := models.ParseMoney("15")
+	if _, err := module.CreateIncome(ctx, userID, 0, funds, "Paging test"); err != nil {
+		t.Fatalf("Failed to create income: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		amount, _ := models.ParseMoney(strconv.Itoa(i))
+		if _, err := module.CreateTransaction(ctx, userID, 0, amount, category, "paging"); err != nil {
+			t.Fatalf("Failed to create transaction: %v", err)
+		}
+	}
+
+	amounts := func(transactions []models.Transaction) string {
+		var s string
+		for _, transaction := range transactions {
+			s += transaction.Amount.String() + " "
+		}
+		return s
+	}
+
+	query := models.ListQuery{Category: category, Sort: "amount", Order: "asc", Limit: 2}
+	first, page, err := module.GetTransactions(ctx, userID, query)
+	if err != nil {
+		t.Fatalf("Failed to list transactions: %v", err)
+	}
+	if amounts(first) != "1 2 " || page.Total != 5 || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page %s: %+v", amounts(first), page)
+	}
+	if page.NextOffset == nil || *page.NextOffset != 2 {
+		t.Errorf("Expected the next offset to be 2, got %v", page.NextOffset)
+	}
+
+	// Following the cursors walks the rest without repeating a row.
+	cursor := page.NextCursor
+	query.Cursor = cursor
+	second, page, err := module.GetTransactions(ctx, userID, query)
+	if err != nil {
+		t.Fatalf("Failed to list the second page: %v", err)
+	}
+	if amounts(second) != "3 4 " || !page.HasMore || page.NextOffset != nil {
+		t.Errorf("Unexpected second page %s: %+v", amounts(second), page)
+	}
+	query.Cursor = page.NextCursor
+	last, page, err := module.GetTransactions(ctx, userID, query)
+	if err != nil {
+		t.Fatalf("Failed to list the last page: %v", err)
+	}
+	if amounts(last) != "5 " || page.HasMore || page.NextCursor != "" {
+		t.Errorf("Unexpected last page %s: %+v", amounts(last), page)
+	}
+
+	// The extra row fetched to detect a next page is never returned.
+	query.Cursor = ""
+	query.Offset = 3
+	_, page, err = module.GetTransactions(ctx, userID, query)
+	if err != nil {
+		t.Fatalf("Failed to list by offset: %v", err)
+	}
+	if page.HasMore || page.NextOffset != nil {
+		t.Errorf("Expected the page ending with the last row to have no next page: %+v", page)
+	}
+
+	// A cursor only continues the sort and order it was issued for.
+	for _, mismatched := range []models.ListQuery{
+		{Category: category, Sort: "created_at", Order: "asc", Cursor: cursor},
+		{Category: category, Sort: "amount", Order: "desc", Cursor: cursor},
+	} {
+		if _, _, err := module.GetTransactions(ctx, userID, mismatched); !module.IsListQueryError(err) {
+			t.Errorf("Expected the cursor to be rejected for %s %s, got %v", mismatched.Sort, mismatched.Order, err)
+		}
+	}
+
+	for _, test := range []struct{ limit, want int }{{0, module.DefaultListLimit}, {10000, module.MaxListLimit}} {
+		_, page, err := module.GetTransactions(ctx, userID, models.ListQuery{Category: category, Limit: test.limit})
+		if err != nil {
+			t.Fatalf("Failed to list transactions: %v", err)
+		}
+		if page.Limit != test.want {
+			t.Errorf("Expected limit %d to be served as %d, got %d", test.limit, test.want, page.Limit)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/middlewares"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsMethodLabelOverKeepAlive(t *testing.T) {
+	const route = "/metrics-test/methods"
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(middlewares.Metrics())
+	handler := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Get(route, handler)
+	app.Post(route, handler)
+	app.Delete(route, handler)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	// Every request goes over the same connection, so Fiber reuses its
+	// request buffers between them.
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	methods := []string{"GET", "POST", "DELETE", "GET", "POST"}
+	for _, method := range methods {
+		fmt.Fprintf(conn, "%s %s HTTP/1.1\r\nHost: localhost\r\nContent-Length: 0\r\n\r\n", method, route)
+		response, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			t.Fatalf("Failed to read the %s response: %v", method, err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNoContent {
+			t.Fatalf("Expected 204 for %s, got %d", method, response.StatusCode)
+		}
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "savecash_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == route {
+				counts[labels["method"]] += metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	want := map[string]float64{"GET": 2, "POST": 2, "DELETE": 1}
+	if len(counts) != len(want) {
+		t.Errorf("Expected one series per method, got %v", counts)
+	}
+	for method, count := range want {
+		if counts[method] != count {
+			t.Errorf("Expected %v %s requests, got %v (series: %v)", count, method, counts[method], counts)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/migrations"
+)
+
+func TestMigrationsAreSequential(t *testing.T) {
+	all, err := migrations.All()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+
+	for i, migration := range all {
+		if migration.Version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, migration.Version)
+		}
+		// The runner wraps each migration in its own database transaction.
+		for _, script := range []string{migration.Up, migration.Down} {
+			if strings.Contains(script, "BEGIN;") || strings.Contains(script, "COMMIT;") {
+				t.Errorf("Migration %04d_%s must not manage its own transaction", migration.Version, migration.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0", "0"},
+		{"12", "12"},
+		{"12.50", "12.5"},
+		{"-3.0001", "-3.0001"},
+		{"0.1", "0.1"},
+		{"1000000000.25", "1000000000.25"},
+	}
+
+	for _, tt := range tests {
+		amount, err := models.ParseMoney(tt.input)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if amount.String() != tt.want {
+			t.Errorf("ParseMoney(%q) = %s, want %s", tt.input, amount, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "1.", "1e3", "1,000", "0.00001"} {
+		if _, err := models.ParseMoney(input); err == nil {
+			t.Errorf("Expected error for %q, but got none", input)
+		}
+	}
+}
+
+func TestMoneyArithmeticIsExact(t *testing.T) {
+	tenCents, _ := models.ParseMoney("0.1")
+	var total models.Money
+	for i := 0; i < 10; i++ {
+		total += tenCents
+	}
+	if total.String() != "1" {
+		t.Errorf("Expected 10 x 0.1 to be 1, got %s", total)
+	}
+}
+
+func TestMoneyPrecisionAndRounding(t *testing.T) {
+	amount, _ := models.ParseMoney("10.5")
+	if amount.HasPlaces(0) {
+		t.Errorf("Expected 10.5 not to fit a currency without decimals")
+	}
+	if !amount.HasPlaces(2) {
+		t.Errorf("Expected 10.5 to fit a currency with two decimals")
+	}
+	if got := amount.Round(0).String(); got != "11" {
+		t.Errorf("Expected 10.5 to round to 11, got %s", got)
+	}
+	if got := amount.MulRate(15000).String(); got != "157500" {
+		t.Errorf("Expected 10.5 x 15000 = 157500, got %s", got)
+	}
+	if got := amount.StringFixed(2); got != "10.50" {
+		t.Errorf("Expected 10.50, got %s", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var body struct {
+		Amount models.Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 19.99}`), &body); err != nil {
+		t.Fatalf("Failed to unmarshal amount: %v", err)
+	}
+	data, _ := json.Marshal(body)
+	if string(data) != `{"amount":19.99}` {
+		t.Errorf("Expected exact JSON number, got %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": 1.23456}`), &body); err == nil {
+		t.Errorf("Expected error for too many decimals, but got none")
+	}
+}
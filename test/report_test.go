@@ -0,0 +1,73 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func TestReportRange(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	now := time.Now().In(jakarta)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, jakarta)
+
+	tests := []struct {
+		name                      string
+		from, to, tz, granularity string
+		wantFrom, wantTo          time.Time
+		err                       string
+	}{
+		{
+			name: "explicit dates default to UTC", from: "2024-01-01", to: "2024-01-31", granularity: module.ReportGranularityMonth,
+			wantFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "dates are read in the timezone", from: "2024-03-01", to: "2024-03-01", tz: "Asia/Jakarta", granularity: module.ReportGranularityDay,
+			wantFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, jakarta), wantTo: time.Date(2024, 3, 2, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name: "twelve months by default", to: "2024-06-15", granularity: module.ReportGranularityMonth,
+			wantFrom: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "thirty days by default", to: "2024-03-30", granularity: module.ReportGranularityDay,
+			wantFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "twelve weeks from a Monday by default", to: "2024-03-27", granularity: module.ReportGranularityWeek,
+			wantFrom: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "five years by default", to: "2024-03-27", granularity: module.ReportGranularityYear,
+			wantFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "up to today by default", from: "2024-01-01", tz: "Asia/Jakarta", granularity: module.ReportGranularityMonth,
+			wantFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, jakarta), wantTo: tomorrow,
+		},
+		{name: "unknown timezone", tz: "Mars/Olympus", granularity: module.ReportGranularityMonth, err: "unknown timezone"},
+		{name: "malformed from", from: "01/02/2024", granularity: module.ReportGranularityMonth, err: "from must be a date"},
+		{name: "malformed to", to: "2024-13-01", granularity: module.ReportGranularityMonth, err: "to must be a date"},
+		{name: "from after to", from: "2024-02-02", to: "2024-02-01", granularity: module.ReportGranularityMonth, err: "from must not be after to"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, to, err := module.ReportRange(test.from, test.to, test.tz, test.granularity)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse the range: %v", err)
+			}
+			if !from.Equal(test.wantFrom) || !to.Equal(test.wantTo) {
+				t.Errorf("Expected [%s, %s), got [%s, %s)", test.wantFrom, test.wantTo, from, to)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func TestSearchQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"coffee", "coffee:*"},
+		{"  Coffee   BEANS ", "coffee:* & beans:*"},
+		{"rent & !utilities | (gas):*", "rent:* & utilities:* & gas:*"},
+		{"o'neil's café 2024", "o:* & neil:* & s:* & café:* & 2024:*"},
+		{"a b c d e f g h i j k l", "a:* & b:* & c:* & d:* & e:* & f:* & g:* & h:* & i:* & j:*"},
+	}
+
+	for _, test := range tests {
+		got, err := module.SearchQuery(test.text)
+		if err != nil {
+			t.Errorf("Failed to build the query for %q: %v", test.text, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Expected %q for %q, got %q", test.want, test.text, got)
+		}
+	}
+
+	for _, text := range []string{"", "   ", "&|!():*"} {
+		if _, err := module.SearchQuery(text); err == nil || !strings.Contains(err.Error(), "must contain a word") {
+			t.Errorf("Expected %q to be rejected, got %v", text, err)
+		}
+	}
+}
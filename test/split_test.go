@@ -0,0 +1,65 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/models"
+	"github.com/Sc01100100/SaveCash-API/module"
+)
+
+func newSplit(category, amount string) models.TransactionSplit {
+	parsed, _ := models.ParseMoney(amount)
+	return models.TransactionSplit{Category: category, Amount: parsed}
+}
+
+func TestValidateSplits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		splits   []models.TransactionSplit
+		err      string
+	}{
+		{"no splits", "100", "USD", nil, ""},
+		{"adding up", "100", "USD", []models.TransactionSplit{newSplit("Food", "60.50"), newSplit("Home", "39.50")}, ""},
+		{"single split", "100", "USD", []models.TransactionSplit{newSplit("Food", "100")}, "at least two splits"},
+		{"short of the total", "100", "USD", []models.TransactionSplit{newSplit("Food", "60"), newSplit("Home", "30")}, "add up to 90"},
+		{"over the total", "100", "USD", []models.TransactionSplit{newSplit("Food", "60"), newSplit("Home", "50")}, "add up to 110"},
+		{"zero amount", "100", "USD", []models.TransactionSplit{newSplit("Food", "100"), newSplit("Home", "0")}, "split 2: amount must be greater than zero"},
+		{"negative amount", "100", "USD", []models.TransactionSplit{newSplit("Food", "110"), newSplit("Home", "-10")}, "split 2: amount must be greater than zero"},
+		{"too precise", "100", "JPY", []models.TransactionSplit{newSplit("Food", "50.5"), newSplit("Home", "49.5")}, "split 1: amount 50.5 has more decimal places"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			amount, _ := models.ParseMoney(test.amount)
+			err := module.ValidateSplits(amount, test.currency, test.splits)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("Expected valid splits, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestMainSplitCategory(t *testing.T) {
+	tests := []struct {
+		splits []models.TransactionSplit
+		want   string
+	}{
+		{[]models.TransactionSplit{newSplit("Food", "10"), newSplit("Home", "30"), newSplit("Fun", "20")}, "Home"},
+		{[]models.TransactionSplit{newSplit("Food", "25"), newSplit("Home", "25")}, "Food"},
+	}
+
+	for _, test := range tests {
+		if got := module.MainSplitCategory(test.splits); got != test.want {
+			t.Errorf("Expected main category %s, got %s", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Sc01100100/SaveCash-API/utils"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storage, err := utils.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	if err := storage.Put("1/ab/abcdef", strings.NewReader("receipt")); err != nil {
+		t.Fatalf("Failed to store object: %v", err)
+	}
+
+	content, err := storage.Open("1/ab/abcdef")
+	if err != nil {
+		t.Fatalf("Failed to open object: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "receipt" {
+		t.Errorf("Expected the stored content, got %q", data)
+	}
+
+	if err := storage.Delete("1/ab/abcdef"); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+	if _, err := storage.Open("1/ab/abcdef"); !errors.Is(err, utils.ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound after delete, got %v", err)
+	}
+
+	if err := storage.Put("../escape", strings.NewReader("x")); err == nil {
+		t.Error("Expected a key outside the storage root to be rejected")
+	}
+}
@@ -1,25 +1,36 @@
 package test
 
 import (
+	"context"
+	"log"
 	"testing"
 	"os"
 
+	"github.com/Sc01100100/SaveCash-API/models"
 	"github.com/Sc01100100/SaveCash-API/module"
 	"github.com/Sc01100100/SaveCash-API/config"
+	"github.com/Sc01100100/SaveCash-API/utils"
 )
 
 func TestMain(m *testing.M) {
-	config.ConnectDB()
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if _, err := config.ConnectDB(cfg.Database); err != nil {
+		log.Fatalf("Database connection failed: %v", err)
+	}
+	utils.ConfigureJWT(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
 	code := m.Run()
 	defer config.Database.Close()
 	os.Exit(code)
 }
 func TestCreateIncome(t *testing.T) {
 	userID := 1
-	amount := 1000.0
+	amount, _ := models.ParseMoney("1000")
 	source := "Salary"
 
-	createdIncome, err := module.CreateIncome(userID, amount, source)
+	createdIncome, err := module.CreateIncome(context.Background(), userID, 0, amount, source)
 	if err != nil {
 		t.Errorf("Failed to create income: %v", err)
 	} else {
@@ -33,7 +44,7 @@ func TestCreateIncome(t *testing.T) {
 			t.Errorf("Expected UserID %d, but got %d", userID, createdIncome.UserID)
 		}
 		if createdIncome.Amount != amount {
-			t.Errorf("Expected Amount %.2f, but got %.2f", amount, createdIncome.Amount)
+			t.Errorf("Expected Amount %s, but got %s", amount, createdIncome.Amount)
 		}
 		if createdIncome.Source != source {
 			t.Errorf("Expected Source %s, but got %s", source, createdIncome.Source)
@@ -42,11 +53,11 @@ func TestCreateIncome(t *testing.T) {
 }
 func TestCreateTransaction(t *testing.T) {
 	userID := 1
-	amount := 500.0
+	amount, _ := models.ParseMoney("500")
 	category := "buy car"
 	description := "buy car for "
 
-	transaction, err := module.CreateTransaction(userID, amount, category, description)
+	transaction, err := module.CreateTransaction(context.Background(), userID, 0, amount, category, description)
 	if err != nil {
 		t.Errorf("Failed to create transaction: %v", err)
 	} else {
@@ -59,8 +70,8 @@ func TestCreateTransaction(t *testing.T) {
 		t.Logf("Transaction created with ID: %d, Category: %s", transaction.ID, transaction.Category)
 	}
 
-	amount = -100.0
-	_, err = module.CreateTransaction(userID, amount, category, description)
+	amount, _ = models.ParseMoney("-100")
+	_, err = module.CreateTransaction(context.Background(), userID, 0, amount, category, description)
 	if err == nil {
 		t.Errorf("Expected error for negative amount, but got none")
 	} else {
@@ -68,7 +79,7 @@ func TestCreateTransaction(t *testing.T) {
 	}
 
 	category = ""
-	_, err = module.CreateTransaction(userID, amount, category, description)
+	_, err = module.CreateTransaction(context.Background(), userID, 0, amount, category, description)
 	if err == nil {
 		t.Errorf("Expected error for empty category, but got none")
 	} else {
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Sc01100100/SaveCash-API/module"
@@ -12,7 +13,7 @@ func TestInsertUser(t *testing.T) {
 	password := "securepassword"
 	role := "user" 
 
-	insertedID, err := module.InsertUser(name, email, password, role)
+	insertedID, err := module.InsertUser(context.Background(), name, email, password, role)
 
 	if err != nil {
 		t.Errorf("Failed to insert user: %v", err)
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// currencyExponents lists the supported ISO 4217 currencies with the number of
+// digits after the decimal separator of their minor unit.
+var currencyExponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BDT": 2, "BHD": 3, "BND": 2, "BRL": 2,
+	"CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+	"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KHR": 2, "KRW": 0, "KWD": 3,
+	"LAK": 2, "LKR": 2, "MMK": 2, "MXN": 2, "MYR": 2, "NGN": 2, "NOK": 2,
+	"NZD": 2, "OMR": 3, "PHP": 2, "PKR": 2, "PLN": 2, "QAR": 2, "RUB": 2,
+	"SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+	"UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// NormalizeCurrency upper-cases and validates an ISO 4217 currency code.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencyExponents[code]; !ok {
+		return "", fmt.Errorf("unsupported currency: %q", code)
+	}
+	return code, nil
+}
+
+// CurrencyExponent returns the number of minor unit digits of a currency.
+func CurrencyExponent(code string) (int, bool) {
+	exponent, ok := currencyExponents[code]
+	return exponent, ok
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// TableWriter writes a table one row at a time so exports never hold the full
+// result set in memory. Flush pushes buffered rows to the underlying writer
+// where the format allows it; Close finishes the file.
+type TableWriter interface {
+	WriteRow(values ...interface{}) error
+	Flush() error
+	Close() error
+}
+
+// ExportContentType returns the MIME type of an export format.
+func ExportContentType(format string) (string, bool) {
+	switch format {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8", true
+	case ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", true
+	}
+	return "", false
+}
+
+// NewTableWriter returns a TableWriter for format writing to w. The sheet name
+// is only used by XLSX.
+func NewTableWriter(format string, w io.Writer, sheet string) (TableWriter, error) {
+	switch format {
+	case ExportFormatCSV:
+		return &csvTableWriter{writer: csv.NewWriter(w)}, nil
+	case ExportFormatXLSX:
+		return newXLSXTableWriter(w, sheet)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// formatCell renders a value as text. Amounts (anything with a Float64
+// method) keep their exact decimal form through String.
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+type csvTableWriter struct {
+	writer *csv.Writer
+}
+
+func (t *csvTableWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatCell(value)
+	}
+	return t.writer.Write(record)
+}
+
+func (t *csvTableWriter) Flush() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+func (t *csvTableWriter) Close() error {
+	return t.Flush()
+}
+
+// xlsxTableWriter uses excelize's stream writer, which spills rows to a
+// temporary file instead of keeping the sheet in memory. The workbook can only
+// be written out once complete, so Flush is a no-op.
+type xlsxTableWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXTableWriter(w io.Writer, sheet string) (*xlsxTableWriter, error) {
+	file := excelize.NewFile()
+	if sheet != "" && sheet != "Sheet1" {
+		if err := file.SetSheetName("Sheet1", sheet); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to name sheet: %w", err)
+		}
+	} else {
+		sheet = "Sheet1"
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create sheet writer: %w", err)
+	}
+
+	return &xlsxTableWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (t *xlsxTableWriter) WriteRow(values ...interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case interface{ Float64() float64 }:
+			cells[i] = v.Float64()
+		case int, int64, float64, bool:
+			cells[i] = v
+		default:
+			cells[i] = formatCell(value)
+		}
+	}
+
+	t.row++
+	cell, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+	return t.stream.SetRow(cell, cells)
+}
+
+func (t *xlsxTableWriter) Flush() error {
+	return nil
+}
+
+func (t *xlsxTableWriter) Close() error {
+	defer t.file.Close()
+
+	if err := t.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to finish sheet: %w", err)
+	}
+	if err := t.file.Write(t.out); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
@@ -1,16 +1,24 @@
 package utils
 
 import (
-	"os"
 	"errors"
 	"time"
-	"log"
 	"strconv"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+var (
+	jwtSecret []byte
+	jwtTTL    = 24 * time.Hour
+)
+
+// ConfigureJWT sets the secret that signs tokens and how long issued tokens
+// stay valid. It must be called before tokens are issued or validated.
+func ConfigureJWT(secret string, ttl time.Duration) {
+	jwtSecret = []byte(secret)
+	jwtTTL = ttl
+}
 
 type JWTClaims struct {
 	UserID string `json:"user_id"`
@@ -23,7 +31,7 @@ func GenerateJWT(userID, role string) (string, error) {
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(jwtTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -35,28 +43,22 @@ func GenerateJWT(userID, role string) (string, error) {
 func ValidateJWT(tokenString string) (int, string, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Printf("Unexpected signing method: %v\n", token.Header["alg"])
 			return nil, errors.New("unexpected signing method")
 		}
 		return jwtSecret, nil
 	})
 
 	if err != nil {
-		log.Printf("Error parsing token: %v\n", err)
 		return 0, "", err
 	}
 
 	claims, ok := token.Claims.(*JWTClaims)
 	if !ok || !token.Valid {
-		log.Println("Invalid token claims or token is not valid")
 		return 0, "", errors.New("invalid token")
 	}
 
-	log.Printf("Parsed token claims: UserID: %s, Role: %s\n", claims.UserID, claims.Role)
-
 	userID, err := strconv.Atoi(claims.UserID)
 	if err != nil || userID == 0 {
-		log.Printf("Invalid UserID in token claims: %v\n", claims.UserID)
 		return 0, "", errors.New("invalid UserID in token claims")
 	}
 
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it
+// serves. Records logged with that context include the ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewLogger returns a logger writing records at level and above to w, as JSON
+// or, when format is "text", as key=value pairs. Every record carries the
+// request ID and trace ID of its context, and emails, tokens and passwords are redacted.
+func NewLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// contextHandler adds the request ID and the trace of the record's context,
+// so that log lines can be matched with their spans.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// secretKeys name attributes whose values are never logged.
+var secretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"authorization": true,
+	"secret":        true,
+	"signature":     true,
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]*\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+\S+`)
+)
+
+const redacted = "[REDACTED]"
+
+// maskEmail keeps the first letter and the domain of an email address.
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// Redact masks the email addresses and removes the bearer and JSON web tokens
+// found in s.
+func Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	return emailPattern.ReplaceAllStringFunc(s, maskEmail)
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	key := strings.ToLower(attr.Key)
+	if secretKeys[key] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		if key == "email" {
+			return slog.String(attr.Key, maskEmail(attr.Value.String()))
+		}
+		attr.Value = slog.StringValue(Redact(attr.Value.String()))
+	case slog.KindGroup:
+		attrs := attr.Value.Group()
+		redactedAttrs := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redactedAttrs[i] = redactAttr(append(groups, attr.Key), a)
+		}
+		attr.Value = slog.GroupValue(redactedAttrs...)
+	case slog.KindLogValuer:
+		attr.Value = attr.Value.Resolve()
+		return redactAttr(groups, attr)
+	case slog.KindAny:
+		// Structs, maps and Stringers are checked through their printed form,
+		// and only logged as text when it holds something to redact.
+		value := attr.Value.Any()
+		text := fmt.Sprint(value)
+		if masked := Redact(text); masked != text {
+			attr.Value = slog.StringValue(masked)
+		} else if err, ok := value.(error); ok {
+			attr.Value = slog.StringValue(err.Error())
+		}
+	}
+	return attr
+}